@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+	"github.com/ulexxander/weather-prometheus-exporters/netatmo"
+)
+
+// authorize runs Netatmo authorization code flow once to bootstrap token file.
+// User grants access on consent page and gets redirected back to local HTTP server with the code.
+func authorize(ctx context.Context, config *config.Netatmo, args []string, log *log.Logger) error {
+	flags := flag.NewFlagSet("authorize", flag.ContinueOnError)
+	redirectURI := flags.String("redirect-uri", "http://localhost:8080/callback", "Redirect URI registered in Netatmo app, code is accepted on its address")
+	accountName := flags.String("account", "", "Name of Netatmo account to authorize, required when multiple are configured")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	account, err := netatmo.FindAccount(config, *accountName)
+	if err != nil {
+		return err
+	}
+	if account.TokenFile == "" {
+		return errors.New("token file is not configured for Netatmo")
+	}
+
+	redirect, err := url.Parse(*redirectURI)
+	if err != nil {
+		return fmt.Errorf("parsing redirect URI: %w", err)
+	}
+
+	creds, err := netatmo.ResolveCredentials(account)
+	if err != nil {
+		return err
+	}
+
+	store := netatmo.NewFileTokenStore(account.TokenFile)
+	oauth := netatmo.NewStoredOAuth(creds.ClientID, creds.ClientSecret, store)
+	scope := netatmo.Scope(config)
+
+	state, err := randomState()
+	if err != nil {
+		return fmt.Errorf("generating state: %w", err)
+	}
+
+	codes := make(chan string, 1)
+	errs := make(chan error, 1)
+
+	callbackPath := redirect.Path
+	if callbackPath == "" {
+		callbackPath = "/"
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(callbackPath, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("state") != state {
+			http.Error(w, "State mismatch", http.StatusBadRequest)
+			return
+		}
+		if reason := query.Get("error"); reason != "" {
+			http.Error(w, "Authorization failed: "+reason, http.StatusBadRequest)
+			select {
+			case errs <- fmt.Errorf("authorization failed: %s", reason):
+			default:
+			}
+			return
+		}
+		fmt.Fprintln(w, "Authorization code received, you can close this page.")
+		select {
+		case codes <- query.Get("code"):
+		default:
+		}
+	})
+
+	server := http.Server{
+		Addr:    redirect.Host,
+		Handler: mux,
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errs <- fmt.Errorf("serving HTTP: %w", err)
+		}
+	}()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Println("Error shutting down HTTP server:", err)
+		}
+	}()
+
+	log.Println("Open following URL in browser and grant access:", oauth.AuthorizeURL(*redirectURI, scope, state))
+	log.Println("Waiting for redirect on", *redirectURI)
+
+	var code string
+	select {
+	case code = <-codes:
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	token, err := oauth.Exchange(code, *redirectURI, scope)
+	if err != nil {
+		return fmt.Errorf("exchanging authorization code: %w", err)
+	}
+	if err := store.Save(token); err != nil {
+		return fmt.Errorf("saving token: %w", err)
+	}
+
+	log.Println("Saved Netatmo token to", account.TokenFile)
+	return nil
+}
+
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
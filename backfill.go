@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+	"github.com/ulexxander/weather-prometheus-exporters/netatmo"
+)
+
+// backfill writes historical Netatmo measurements as OpenMetrics text,
+// which can be imported with "promtool tsdb create-blocks-from openmetrics".
+func backfill(config *config.Netatmo, args []string, log *log.Logger) error {
+	flags := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	from := flags.String("from", "", "Start of time range, RFC 3339 like 2022-05-01T00:00:00Z")
+	to := flags.String("to", "", "End of time range, RFC 3339, defaults to now")
+	scale := flags.String("scale", netatmo.Scale30Minutes, "Time between measurements: max (5 minutes), 30min, 1hour, 3hours, 1day, 1week or 1month")
+	output := flags.String("output", "", "File to write OpenMetrics into, defaults to stdout")
+	accountName := flags.String("account", "", "Name of Netatmo account to backfill, required when multiple are configured")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *from == "" {
+		return errors.New("start of time range is required")
+	}
+	fromTime, err := time.Parse(time.RFC3339, *from)
+	if err != nil {
+		return fmt.Errorf("parsing start of time range: %w", err)
+	}
+	toTime := time.Now()
+	if *to != "" {
+		toTime, err = time.Parse(time.RFC3339, *to)
+		if err != nil {
+			return fmt.Errorf("parsing end of time range: %w", err)
+		}
+	}
+
+	account, err := netatmo.FindAccount(config, *accountName)
+	if err != nil {
+		return err
+	}
+	creds, err := netatmo.ResolveCredentials(account)
+	if err != nil {
+		return err
+	}
+	client := netatmo.NewAccountClient(account, creds, netatmo.Scope(config))
+
+	backfill := netatmo.NewBackfill(client, *scale, log)
+	if account.Name != "" {
+		backfill.ConstLabels = prometheus.Labels{"account": account.Name}
+	}
+
+	log.Printf("Backfilling Netatmo measurements from %s to %s with scale %s", fromTime, toTime, *scale)
+	families, err := backfill.MetricFamilies(fromTime, toTime)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("creating output file: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	w := bufio.NewWriter(out)
+	for _, family := range families {
+		if _, err := expfmt.MetricFamilyToOpenMetrics(w, family); err != nil {
+			return fmt.Errorf("writing OpenMetrics: %w", err)
+		}
+	}
+	if _, err := expfmt.FinalizeOpenMetrics(w); err != nil {
+		return fmt.Errorf("writing OpenMetrics: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("flushing output: %w", err)
+	}
+
+	if *output != "" {
+		log.Println("Wrote OpenMetrics to", *output)
+	}
+	return nil
+}
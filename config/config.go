@@ -8,33 +8,190 @@ import (
 type Config struct {
 	Netatmo     Netatmo
 	OpenWeather OpenWeather
+	// Sections are decoded sections of sources that are not fields of Config, by their names, see RegisterSection.
+	Sections map[string]interface{} `json:"-"`
 }
 
 type Netatmo struct {
+	// TokenFile enables authorization code flow, where token pair is kept in this file.
+	// It has to be bootstrapped with "authorize" command once.
+	// Username and password are not needed then.
+	TokenFile string
+	// ClientID and ClientSecret of Netatmo app default to NETATMO_CLIENT_ID and NETATMO_CLIENT_SECRET environment variables.
+	ClientID     Secret
+	ClientSecret Secret
+	// Username and Password are needed only without TokenFile,
+	// they default to NETATMO_USERNAME and NETATMO_PASSWORD environment variables.
+	Username Secret
+	Password Secret
+	// Accounts replace TokenFile and credentials when multiple Netatmo accounts are exported.
+	// Collectors of user devices run for each account, their series get account label.
+	Accounts     []NetatmoAccount
 	StationsData NetatmoStationsData
+	PublicData   NetatmoPublicData
+	// HomeCoachsData requires read_homecoach scope, which has to be granted on authorize when TokenFile is used.
+	HomeCoachsData NetatmoHomeCoachsData
+	// HomeStatus requires read_thermostat scope, which has to be granted on authorize when TokenFile is used.
+	HomeStatus NetatmoHomeStatus
+}
+
+// AllAccounts returns Accounts, or single unnamed account of TokenFile and credentials when none are configured.
+func (n *Netatmo) AllAccounts() []NetatmoAccount {
+	if len(n.Accounts) == 0 {
+		return []NetatmoAccount{{
+			TokenFile:    n.TokenFile,
+			ClientID:     n.ClientID,
+			ClientSecret: n.ClientSecret,
+			Username:     n.Username,
+			Password:     n.Password,
+		}}
+	}
+	return n.Accounts
+}
+
+type NetatmoAccount struct {
+	// Name is exported as account label.
+	Name string
+	// TokenFile is the same as Netatmo.TokenFile, but of this account.
+	TokenFile string
+	// EnvPrefix of environment variables that credentials default to, like "NETATMO_HOME_" for NETATMO_HOME_CLIENT_ID.
+	// Defaults to "NETATMO_", which is enough for accounts authorized in the same Netatmo app with TokenFile.
+	EnvPrefix    string
+	ClientID     Secret
+	ClientSecret Secret
+	Username     Secret
+	Password     Secret
 }
 
 type NetatmoStationsData struct {
 	Enabled  bool
 	Interval Duration
+	// StaleGracePeriod is how long series of disappeared stations and modules are kept exported.
+	StaleGracePeriod Duration
+	// SampleTimestamps attaches measurement time to samples of dashboard data,
+	// so Prometheus stores them at the time they were measured instead of scrape time.
+	SampleTimestamps bool
+}
+
+type NetatmoHomeCoachsData struct {
+	Enabled  bool
+	Interval Duration
+	// StaleGracePeriod is how long series of disappeared devices are kept exported.
+	StaleGracePeriod Duration
+	// SampleTimestamps attaches measurement time to samples of dashboard data,
+	// so Prometheus stores them at the time they were measured instead of scrape time.
+	SampleTimestamps bool
+}
+
+type NetatmoHomeStatus struct {
+	Enabled  bool
+	Interval Duration
+	// StaleGracePeriod is how long series of removed rooms and modules are kept exported.
+	StaleGracePeriod Duration
+}
+
+type NetatmoPublicData struct {
+	Enabled  bool
+	Areas    []NetatmoArea
+	Interval Duration
+	// StaleGracePeriod is how long series of areas without stations are kept exported.
+	StaleGracePeriod Duration
+}
+
+// NetatmoArea is bounding box of public stations that are aggregated together.
+type NetatmoArea struct {
+	// Name is exported as area label.
+	Name  string
+	LatNE float64
+	LonNE float64
+	LatSW float64
+	LonSW float64
+	// Filter excludes stations with abnormal temperature measurements.
+	Filter bool
 }
 
 type OpenWeather struct {
+	// AppID defaults to OPEN_WEATHER_APP_ID environment variable.
+	AppID              Secret
 	CurrentWeatherData OpenWeatherCurrentWeatherData
+	OneCall            OpenWeatherOneCall
+	AirPollution       OpenWeatherAirPollution
+	Forecast           OpenWeatherForecast
 }
 
 type OpenWeatherCurrentWeatherData struct {
 	Enabled  bool
 	Coords   []Coordinates
 	Interval Duration
+	// StaleGracePeriod is how long series of locations missing from responses are kept exported.
+	StaleGracePeriod Duration
+	// SampleTimestamps attaches data calculation time to samples,
+	// so Prometheus stores them at the time they were calculated instead of scrape time.
+	SampleTimestamps bool
 }
 
+type OpenWeatherOneCall struct {
+	Enabled bool
+	// Coords of locations, One Call API accepts only Lat with Lon.
+	Coords   []Coordinates
+	Interval Duration
+	// StaleGracePeriod is how long series of locations and forecast horizons missing from responses are kept exported.
+	StaleGracePeriod Duration
+	// SampleTimestamps attaches data calculation time to samples of current weather.
+	// Forecasts are never timestamped, Prometheus rejects samples that far in the future.
+	SampleTimestamps bool
+	// Hours limits hourly forecast to this many hours ahead, all of them (up to 47) are exported when it is 0.
+	Hours int
+	// Days limits daily forecast to this many days ahead, all of them (up to 7) are exported when it is 0.
+	Days int
+}
+
+type OpenWeatherAirPollution struct {
+	Enabled bool
+	// Coords of locations, Air Pollution API accepts only Lat with Lon.
+	// Coords of Current Weather Data are used when they are not set.
+	Coords   []Coordinates
+	Interval Duration
+	// StaleGracePeriod is how long series of locations missing from responses are kept exported.
+	StaleGracePeriod Duration
+	// SampleTimestamps attaches measurement time to samples,
+	// so Prometheus stores them at the time they were measured instead of scrape time.
+	SampleTimestamps bool
+}
+
+type OpenWeatherForecast struct {
+	Enabled bool
+	// Coords of locations, Coords of Current Weather Data are used when they are not set.
+	Coords   []Coordinates
+	Interval Duration
+	// StaleGracePeriod is how long series of locations and steps missing from responses are kept exported.
+	StaleGracePeriod Duration
+	// Steps limits forecast to this many 3-hour steps ahead, all of them (up to 40) are exported when it is 0.
+	Steps int
+}
+
+// Coordinates identify location of OpenWeather data.
+// Location is looked up by the first identifier that is set:
+// CityID, Q (city name, optionally with country code, like "Kranj,SI"), Zip ("1000,SI"),
+// and by Lat with Lon when none of them is set.
 type Coordinates struct {
-	Lon float64
-	Lat float64
+	Lon    float64
+	Lat    float64
+	CityID int
+	Q      string
+	Zip    string
+	// Units are "standard" (default, temperature in Kelvin), "metric" or "imperial".
+	Units string
+	// Lang is language of condition descriptions, like "en" or "sl".
+	Lang string
+	// Name is stable name of location exported as location label.
+	// City that OpenWeather resolves location to is still exported as name label.
+	Name string
+	// Labels are attached to every series of location, locations without some of them have them empty.
+	Labels map[string]string
 }
 
-// Duration embeds time.Duration and makes it more JSON-friendly.
+// Duration embeds time.Duration and makes it more JSON and YAML friendly.
 // Instead of marshaling and unmarshaling as int64 it uses strings, like "5m" or "0.5s".
 type Duration time.Duration
 
@@ -52,6 +209,20 @@ func (d *Duration) UnmarshalJSON(data []byte) error {
 	return err
 }
 
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
+
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	val, err := time.ParseDuration(str)
+	*d = Duration(val)
+	return err
+}
+
 func (d Duration) String() string {
 	return time.Duration(d).String()
 }
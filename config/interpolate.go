@@ -0,0 +1,163 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// variablePattern matches ${VAR} and ${VAR:-default}, or $${ escaping literal "${".
+var variablePattern = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// Load decodes config in JSON, or YAML when isYAML is set, replaces environment variables in its string values
+// and parses it as strictly as Parse does.
+// ${VAR} is replaced with value of variable and ${VAR:-default} with default when variable is unset or empty,
+// literal "${" is written as "$${". Variables that are unset and have no default are reported together.
+//
+// Variables are replaced after the document is decoded, so their values can contain quotes, newlines
+// or anything else without changing its structure, and variables in YAML comments are ignored.
+// String that consists of a single variable is converted to number or bool for such fields, like "Lat": "${LAT}".
+func Load(data []byte, isYAML bool, lookup func(key string) (string, bool)) (*Config, error) {
+	var doc interface{}
+	var err error
+	if isYAML {
+		doc, err = decodeYAML(data)
+	} else {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		// Numbers are kept as they are written, so they are not rounded by float64.
+		dec.UseNumber()
+		err = dec.Decode(&doc)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	in := interpolator{lookup: lookup}
+	doc = in.value(doc, configType)
+	if len(in.undefined) > 0 {
+		// Objects are decoded into maps, so variables are sorted to be reported in the same order every time.
+		sort.Strings(in.undefined)
+		return nil, fmt.Errorf("undefined environment variables: %s", strings.Join(in.undefined, ", "))
+	}
+
+	jsonData, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(jsonData)
+}
+
+type interpolator struct {
+	lookup    func(key string) (string, bool)
+	undefined []string
+}
+
+// value replaces variables in strings of decoded document, t is type of config field it is decoded into, or nil if unknown.
+func (in *interpolator) value(val interface{}, t reflect.Type) interface{} {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch val := val.(type) {
+	case string:
+		return in.string(val, t)
+	case map[string]interface{}:
+		for k, v := range val {
+			val[k] = in.value(v, fieldType(t, k))
+		}
+		return val
+	case []interface{}:
+		var elem reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elem = t.Elem()
+		}
+		for i, v := range val {
+			val[i] = in.value(v, elem)
+		}
+		return val
+	default:
+		return val
+	}
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+func (in *interpolator) string(s string, t reflect.Type) interface{} {
+	loc := variablePattern.FindStringIndex(s)
+	single := loc != nil && loc[0] == 0 && loc[1] == len(s)
+	result := variablePattern.ReplaceAllStringFunc(s, in.replace)
+	// Types like Duration decode strings themselves.
+	if !single || t == nil || reflect.PtrTo(t).Implements(unmarshalerType) {
+		return result
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		switch result {
+		case "true":
+			return true
+		case "false":
+			return false
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		var f float64
+		if err := json.Unmarshal([]byte(result), &f); err == nil {
+			return json.Number(result)
+		}
+	}
+	// Parse reports type mismatch of values that are not converted.
+	return result
+}
+
+func (in *interpolator) replace(match string) string {
+	if match == "$${" {
+		return "${"
+	}
+	groups := variablePattern.FindStringSubmatch(match)
+	name := groups[1]
+	val, ok := in.lookup(name)
+	if groups[2] != "" && val == "" {
+		return groups[3]
+	}
+	if !ok && !contains(in.undefined, name) {
+		in.undefined = append(in.undefined, name)
+	}
+	return val
+}
+
+// fieldType returns type of struct field, registered section or map value that key is decoded into,
+// field names are matched case-insensitively like encoding/json does.
+func fieldType(t reflect.Type, key string) reflect.Type {
+	if t == nil {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Map:
+		return t.Elem()
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath == "" && strings.EqualFold(f.Name, key) {
+				return f.Type
+			}
+		}
+		if t == configType {
+			return sectionType(key)
+		}
+	}
+	return nil
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
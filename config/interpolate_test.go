@@ -0,0 +1,116 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+)
+
+func TestLoad(t *testing.T) {
+	env := map[string]string{
+		"INTERVAL": "30s",
+		"EMPTY":    "",
+		"LAT":      "46.24",
+		"ENABLED":  "true",
+		// Values that would break the document if they were substituted into it.
+		"APP_ID":    `a"b\c: d`,
+		"MULTILINE": "first line\nsecond: line\n",
+	}
+	lookup := func(key string) (string, bool) {
+		val, ok := env[key]
+		return val, ok
+	}
+
+	tests := []struct {
+		name   string
+		isYAML bool
+		data   string
+	}{
+		{
+			name: "JSON",
+			data: `{
+				"OpenWeather": {
+					"AppID": "${APP_ID}",
+					"CurrentWeatherData": {
+						"Enabled": "${ENABLED}",
+						"Interval": "${INTERVAL}",
+						"Coords": [{
+							"Lat": "${LAT}",
+							"Lon": 14.36,
+							"Name": "${MULTILINE}",
+							"Lang": "${EMPTY:-sl}",
+							"Q": "pa$$word $${LITERAL}",
+							"Labels": { "default": "${UNSET:-5m}", "empty": "${EMPTY}" }
+						}]
+					}
+				}
+			}`,
+		},
+		{
+			name:   "YAML",
+			isYAML: true,
+			data: `
+# Comments are not interpolated: ${UNSET}
+OpenWeather:
+  AppID: ${APP_ID}
+  CurrentWeatherData:
+    Enabled: ${ENABLED}
+    Interval: ${INTERVAL}
+    Coords:
+      - Lat: ${LAT}
+        Lon: 14.36
+        Name: ${MULTILINE}
+        Lang: ${EMPTY:-sl}
+        Q: pa$$word $${LITERAL}
+        Labels:
+          default: ${UNSET:-5m}
+          empty: "${EMPTY}"
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := config.Load([]byte(tt.data), tt.isYAML, lookup)
+			require.NoError(t, err)
+
+			cwd := cfg.OpenWeather.CurrentWeatherData
+			require.Equal(t, config.Secret{Value: `a"b\c: d`}, cfg.OpenWeather.AppID)
+			require.True(t, cwd.Enabled)
+			require.Equal(t, config.Duration(30*time.Second), cwd.Interval)
+			require.Equal(t, []config.Coordinates{{
+				Lat:    46.24,
+				Lon:    14.36,
+				Name:   "first line\nsecond: line\n",
+				Lang:   "sl",
+				Q:      "pa$$word ${LITERAL}",
+				Labels: map[string]string{"default": "5m", "empty": ""},
+			}}, cwd.Coords)
+		})
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	lookup := func(key string) (string, bool) {
+		if key == "INTERVAL" {
+			return "30s", true
+		}
+		if key == "LAT" {
+			return "north", true
+		}
+		return "", false
+	}
+
+	_, err := config.Load([]byte(`{ "Netatmo": { "TokenFile": "${UNSET} ${INTERVAL} ${OTHER}", "Accounts": [{ "Name": "${UNSET}" }] } }`), false, lookup)
+	require.EqualError(t, err, "undefined environment variables: OTHER, UNSET")
+
+	// Value that is not a number is left as string and rejected by Parse.
+	_, err = config.Load([]byte(`{ "OpenWeather": { "CurrentWeatherData": { "Coords": [{ "Lat": "${LAT}" }] } } }`), false, lookup)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cannot unmarshal string into Go struct field")
+	require.Contains(t, err.Error(), "Lat of type float64")
+
+	_, err = config.Load([]byte(`{ "Netatmo": { "StationsData": { "Intervall": "${INTERVAL}" } } }`), false, lookup)
+	require.EqualError(t, err, `json: unknown field "Intervall"`)
+}
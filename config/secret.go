@@ -0,0 +1,107 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Secret is credential given by one of:
+// literal Value, name of Env variable or path of File, like Docker or Kubernetes secret.
+// Env variable can also name a file with _FILE suffix, like OPEN_WEATHER_APP_ID_FILE.
+// In JSON and YAML it is either a string with literal value or an object with one of the fields.
+// It is never printed or marshaled with its value.
+type Secret struct {
+	Value string
+	Env   string
+	File  string
+}
+
+const redacted = "REDACTED"
+
+func (s *Secret) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		*s = Secret{Value: value}
+		return nil
+	}
+
+	// Alias has no methods, so it is decoded as regular struct.
+	type secret Secret
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode((*secret)(s))
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// String tells where secret comes from, leaving its value out.
+func (s Secret) String() string {
+	switch {
+	case s.Value != "":
+		return redacted
+	case s.Env != "":
+		return "env:" + s.Env
+	case s.File != "":
+		return "file:" + s.File
+	default:
+		return ""
+	}
+}
+
+// GoString prevents value from being printed with %#v.
+func (s Secret) GoString() string {
+	return s.String()
+}
+
+// WithDefaultEnv returns secret, or secret of env variable when it is not configured.
+func (s Secret) WithDefaultEnv(env string) Secret {
+	if s == (Secret{}) {
+		return Secret{Env: env}
+	}
+	return s
+}
+
+// Path returns file that secret is read from, or empty string when it is not read from file.
+func (s Secret) Path() string {
+	if s.Value != "" {
+		return ""
+	}
+	if s.File != "" {
+		return s.File
+	}
+	if s.Env != "" && os.Getenv(s.Env) == "" {
+		return os.Getenv(s.Env + "_FILE")
+	}
+	return ""
+}
+
+// Resolve reads value of secret, file is read again on every call, so its changes are picked up.
+func (s Secret) Resolve() (string, error) {
+	if s.Value != "" {
+		return s.Value, nil
+	}
+	if path := s.Path(); path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("reading secret file: %w", err)
+		}
+		// Files usually end with newline, which is not part of the secret.
+		value := strings.TrimRight(string(content), "\r\n")
+		if value == "" {
+			return "", fmt.Errorf("secret file %s is empty", path)
+		}
+		return value, nil
+	}
+	if s.Env != "" {
+		if value := os.Getenv(s.Env); value != "" {
+			return value, nil
+		}
+		return "", fmt.Errorf("environment variable %s or %s_FILE is not set", s.Env, s.Env)
+	}
+	return "", fmt.Errorf("secret is not configured")
+}
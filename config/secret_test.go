@@ -0,0 +1,61 @@
+package config_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+)
+
+func TestSecret_JSON(t *testing.T) {
+	var secrets []config.Secret
+	err := json.Unmarshal([]byte(`["literal", { "Env": "MY_SECRET" }, { "File": "/run/secrets/my_secret" }]`), &secrets)
+	require.NoError(t, err)
+	require.Equal(t, []config.Secret{
+		{Value: "literal"},
+		{Env: "MY_SECRET"},
+		{File: "/run/secrets/my_secret"},
+	}, secrets)
+
+	data, err := json.Marshal(secrets)
+	require.NoError(t, err)
+	require.Equal(t, `["REDACTED","env:MY_SECRET","file:/run/secrets/my_secret"]`, string(data))
+
+	require.Equal(t, "REDACTED {S:REDACTED}", fmt.Sprintf("%v %+v", secrets[0], struct{ S config.Secret }{secrets[0]}))
+	require.NotContains(t, fmt.Sprintf("%#v", secrets[0]), "literal")
+
+	err = json.Unmarshal([]byte(`{ "Path": "/run/secrets/my_secret" }`), &secrets[0])
+	require.EqualError(t, err, `json: unknown field "Path"`)
+}
+
+func TestSecret_Resolve(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "secret")
+	err := os.WriteFile(file, []byte("from-file\n"), 0600)
+	require.NoError(t, err)
+
+	t.Setenv("TEST_SECRET", "from-env")
+	t.Setenv("TEST_FILE_SECRET_FILE", file)
+
+	for _, tc := range []struct {
+		secret config.Secret
+		path   string
+		value  string
+	}{
+		{config.Secret{Value: "literal"}, "", "literal"},
+		{config.Secret{File: file}, file, "from-file"},
+		{config.Secret{}.WithDefaultEnv("TEST_SECRET"), "", "from-env"},
+		{config.Secret{Env: "TEST_FILE_SECRET"}, file, "from-file"},
+	} {
+		require.Equal(t, tc.path, tc.secret.Path(), tc.secret)
+		value, err := tc.secret.Resolve()
+		require.NoError(t, err, tc.secret)
+		require.Equal(t, tc.value, value, tc.secret)
+	}
+
+	_, err = config.Secret{Env: "TEST_UNSET_SECRET"}.Resolve()
+	require.EqualError(t, err, "environment variable TEST_UNSET_SECRET or TEST_UNSET_SECRET_FILE is not set")
+}
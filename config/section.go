@@ -0,0 +1,108 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	sectionsMu sync.Mutex
+	// sections are constructors of registered sections by their top-level key.
+	sections = map[string]func() interface{}{}
+)
+
+// RegisterSection makes top-level key name a section of config that is decoded into value returned by newSection,
+// it has to be a pointer to struct. Decoded section is available in Config.Sections by name,
+// section missing from config has value of newSection as it is, so its collectors are disabled.
+// It panics when name is already registered or is a field of Config.
+func RegisterSection(name string, newSection func() interface{}) {
+	sectionsMu.Lock()
+	defer sectionsMu.Unlock()
+	if configField(name) != nil {
+		panic(fmt.Sprintf("config section %s is a field of Config", name))
+	}
+	if sectionConstructor(name) != nil {
+		panic(fmt.Sprintf("config section %s is already registered", name))
+	}
+	sections[name] = newSection
+}
+
+var configType = reflect.TypeOf(Config{})
+
+// configField returns field of Config that key is decoded into, keys are matched case-insensitively like encoding/json does.
+func configField(key string) *reflect.StructField {
+	for i := 0; i < configType.NumField(); i++ {
+		f := configType.Field(i)
+		if f.Tag.Get("json") != "-" && strings.EqualFold(f.Name, key) {
+			return &f
+		}
+	}
+	return nil
+}
+
+// sectionConstructor returns constructor of registered section that key is decoded into, or nil if there is none.
+// It has to be called with sectionsMu locked.
+func sectionConstructor(key string) func() interface{} {
+	for name, newSection := range sections {
+		if strings.EqualFold(name, key) {
+			return newSection
+		}
+	}
+	return nil
+}
+
+// sectionType returns type of registered section that key is decoded into, or nil if there is none.
+func sectionType(key string) reflect.Type {
+	sectionsMu.Lock()
+	defer sectionsMu.Unlock()
+	if newSection := sectionConstructor(key); newSection != nil {
+		return reflect.TypeOf(newSection())
+	}
+	return nil
+}
+
+// decodeSections decodes registered sections of config into c.Sections as strictly as Parse does
+// and returns document without them, so the rest of it is decoded into fields of Config.
+func (c *Config) decodeSections(data []byte) ([]byte, error) {
+	sectionsMu.Lock()
+	defer sectionsMu.Unlock()
+	if len(sections) == 0 {
+		return data, nil
+	}
+
+	c.Sections = make(map[string]interface{}, len(sections))
+	for name, newSection := range sections {
+		c.Sections[name] = newSection()
+	}
+
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		// Document that is not an object is reported by decoding it into Config.
+		return data, nil
+	}
+	found := false
+	for key, raw := range doc {
+		for name, newSection := range sections {
+			if !strings.EqualFold(name, key) {
+				continue
+			}
+			section := newSection()
+			dec := json.NewDecoder(bytes.NewReader(raw))
+			dec.DisallowUnknownFields()
+			if err := dec.Decode(section); err != nil {
+				return nil, fmt.Errorf("decoding %s section: %w", name, err)
+			}
+			c.Sections[name] = section
+			delete(doc, key)
+			found = true
+		}
+	}
+	if !found {
+		return data, nil
+	}
+	return json.Marshal(doc)
+}
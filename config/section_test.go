@@ -0,0 +1,76 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+)
+
+// exampleSection is config of source that is not a field of config.Config.
+type exampleSection struct {
+	Enabled  bool
+	Interval config.Duration
+	Lat      float64
+	APIKey   config.Secret
+}
+
+func init() {
+	config.RegisterSection("Example", func() interface{} { return &exampleSection{} })
+}
+
+func TestParse_Sections(t *testing.T) {
+	cfg, err := config.Parse([]byte(`{
+		"OpenWeather": { "AppID": "my-app-id" },
+		"example": { "Enabled": true, "Interval": "5m", "Lat": 46.24, "APIKey": { "Env": "EXAMPLE_API_KEY" } }
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, config.Secret{Value: "my-app-id"}, cfg.OpenWeather.AppID)
+	require.Equal(t, &exampleSection{
+		Enabled:  true,
+		Interval: config.Duration(5 * time.Minute),
+		Lat:      46.24,
+		APIKey:   config.Secret{Env: "EXAMPLE_API_KEY"},
+	}, cfg.Sections["Example"])
+
+	// Source of missing section gets zero config, so it is disabled.
+	cfg, err = config.Parse([]byte(`{}`))
+	require.NoError(t, err)
+	require.Equal(t, &exampleSection{}, cfg.Sections["Example"])
+
+	_, err = config.Parse([]byte(`{ "Example": { "Enabld": true } }`))
+	require.EqualError(t, err, `decoding Example section: json: unknown field "Enabld"`)
+
+	_, err = config.Parse([]byte(`{ "Example": {}, "Unknown": {} }`))
+	require.EqualError(t, err, `json: unknown field "Unknown"`)
+}
+
+func TestLoad_Sections(t *testing.T) {
+	lookup := func(key string) (string, bool) {
+		return map[string]string{"LAT": "46.24", "ENABLED": "true"}[key], true
+	}
+
+	cfg, err := config.Load([]byte(`
+Example:
+  Enabled: ${ENABLED}
+  Interval: 30s
+  Lat: ${LAT}
+`), true, lookup)
+	require.NoError(t, err)
+	require.Equal(t, &exampleSection{
+		Enabled:  true,
+		Interval: config.Duration(30 * time.Second),
+		Lat:      46.24,
+	}, cfg.Sections["Example"])
+}
+
+func TestRegisterSection(t *testing.T) {
+	newSection := func() interface{} { return &exampleSection{} }
+	require.Panics(t, func() {
+		config.RegisterSection("example", newSection)
+	})
+	require.Panics(t, func() {
+		config.RegisterSection("Netatmo", newSection)
+	})
+}
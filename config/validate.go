@@ -0,0 +1,155 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MinInterval is the shortest update interval, shorter ones are most likely mistakes that would exhaust API limits.
+const MinInterval = time.Second
+
+// Parse decodes config strictly, unknown fields like misspelled ones are rejected.
+// Top-level keys of registered sections are decoded into Sections.
+func Parse(data []byte) (*Config, error) {
+	var config Config
+	data, err := config.decodeSections(data)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// ValidationError lists all problems found in config, so they can be fixed at once.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config: " + strings.Join(e.Problems, "; ")
+}
+
+// Validator collects problems of config, sources validate their sections with it.
+type Validator struct {
+	problems []string
+}
+
+// Addf adds problem unless it was already found, Coords can be checked for multiple collectors.
+func (v *Validator) Addf(format string, args ...interface{}) {
+	problem := fmt.Sprintf(format, args...)
+	for _, p := range v.problems {
+		if p == problem {
+			return
+		}
+	}
+	v.problems = append(v.problems, problem)
+}
+
+// Err returns *ValidationError with all problems, or nil when there are none.
+func (v *Validator) Err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: v.problems}
+}
+
+// Interval reports update interval shorter than MinInterval.
+func (v *Validator) Interval(path string, interval Duration) {
+	if time.Duration(interval) < MinInterval {
+		v.Addf("%s.Interval must be at least %s, got %s", path, MinInterval, interval)
+	}
+}
+
+// LatLon reports coordinates out of range.
+func (v *Validator) LatLon(path string, lat, lon float64) {
+	if lat < -90 || lat > 90 {
+		v.Addf("%s.Lat must be between -90 and 90, got %g", path, lat)
+	}
+	if lon < -180 || lon > 180 {
+		v.Addf("%s.Lon must be between -180 and 180, got %g", path, lon)
+	}
+}
+
+// Coords reports locations out of range, with unknown units or invalid labels, and duplicates.
+// Labels must not clash with builtinLabels that are exported by collector itself.
+func (v *Validator) Coords(path string, coords []Coordinates, builtinLabels map[string]bool) {
+	if len(coords) == 0 {
+		v.Addf("%s.Coords must not be empty", path)
+	}
+	seen := map[string]bool{}
+	for i := range coords {
+		c := &coords[i]
+		itemPath := fmt.Sprintf("%s.Coords[%d]", path, i)
+		v.LatLon(itemPath, c.Lat, c.Lon)
+		switch c.Units {
+		case "", "standard", "metric", "imperial":
+		default:
+			v.Addf("%s.Units must be standard, metric or imperial, got %q", itemPath, c.Units)
+		}
+		v.Labels(itemPath, c.Labels, builtinLabels)
+		key := c.key()
+		if seen[key] {
+			v.Addf("%s is a duplicate location", itemPath)
+		}
+		seen[key] = true
+	}
+}
+
+// labelNameRe matches valid Prometheus label names, names starting with __ are reserved for internal use.
+var labelNameRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Labels reports user-defined labels that Prometheus would reject or that clash with builtin ones.
+func (v *Validator) Labels(path string, labels map[string]string, builtin map[string]bool) {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		switch {
+		case !labelNameRe.MatchString(name) || strings.HasPrefix(name, "__"):
+			v.Addf("%s.Labels name %q is not a valid label name", path, name)
+		case builtin[name]:
+			v.Addf("%s.Labels name %q clashes with built-in label", path, name)
+		}
+	}
+}
+
+// LatLonOnly reports locations not given by coordinates, for APIs that accept only them.
+// Otherwise such location would silently be queried at 0, 0.
+func (v *Validator) LatLonOnly(path string, coords []Coordinates) {
+	for i := range coords {
+		if !coords[i].ByLatLon() {
+			v.Addf("%s.Coords[%d] must be given by Lat and Lon, CityID, Q and Zip are not supported", path, i)
+		}
+	}
+}
+
+// Limit reports val that is negative or greater than max.
+func (v *Validator) Limit(path string, val, max int) {
+	if val < 0 || val > max {
+		v.Addf("%s must be between 0 and %d, got %d", path, max, val)
+	}
+}
+
+// ByLatLon reports whether location is given only by Lat and Lon.
+func (c *Coordinates) ByLatLon() bool {
+	return c.CityID == 0 && c.Q == "" && c.Zip == ""
+}
+
+// key identifies location, locations with the same key would be exported as the same series.
+func (c *Coordinates) key() string {
+	if c.Name != "" {
+		return "name:" + c.Name
+	}
+	return fmt.Sprintf("lat:%g lon:%g city:%d q:%s zip:%s units:%s", c.Lat, c.Lon, c.CityID, c.Q, c.Zip, c.Units)
+}
@@ -0,0 +1,81 @@
+package config_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+)
+
+func TestParse(t *testing.T) {
+	cfg, err := config.Parse([]byte(`{
+		"OpenWeather": {
+			"CurrentWeatherData": {
+				"Enabled": true,
+				"Coords": [{ "Lat": 46.24, "Lon": 14.36, "Name": "kranj" }],
+				"Interval": "30s"
+			}
+		}
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, config.Duration(30*time.Second), cfg.OpenWeather.CurrentWeatherData.Interval)
+	require.Equal(t, "kranj", cfg.OpenWeather.CurrentWeatherData.Coords[0].Name)
+
+	_, err = config.Parse([]byte(`{ "Netatmo": { "StationsData": { "Enabled": true, "Intervall": "5m" } } }`))
+	require.EqualError(t, err, `json: unknown field "Intervall"`)
+}
+
+func TestValidator_Coords(t *testing.T) {
+	tests := []struct {
+		name        string
+		coords      config.Coordinates
+		wantProblem string
+	}{
+		{
+			name:   "valid",
+			coords: config.Coordinates{Lat: 46.24, Lon: 14.36, Units: "metric", Labels: map[string]string{"site": "hq", "_region": "gorenjska"}},
+		},
+		{
+			name:        "unknown units",
+			coords:      config.Coordinates{Lat: 46.24, Lon: 14.36, Units: "celsius"},
+			wantProblem: `OpenWeather.CurrentWeatherData.Coords[0].Units must be standard, metric or imperial, got "celsius"`,
+		},
+		{
+			name:        "invalid label name",
+			coords:      config.Coordinates{Lat: 46.24, Lon: 14.36, Labels: map[string]string{"data-center": "ljubljana"}},
+			wantProblem: `OpenWeather.CurrentWeatherData.Coords[0].Labels name "data-center" is not a valid label name`,
+		},
+		{
+			name:        "label name starting with digit",
+			coords:      config.Coordinates{Lat: 46.24, Lon: 14.36, Labels: map[string]string{"1st": "yes"}},
+			wantProblem: `OpenWeather.CurrentWeatherData.Coords[0].Labels name "1st" is not a valid label name`,
+		},
+		{
+			name:        "reserved label name",
+			coords:      config.Coordinates{Lat: 46.24, Lon: 14.36, Labels: map[string]string{"__name__": "temp"}},
+			wantProblem: `OpenWeather.CurrentWeatherData.Coords[0].Labels name "__name__" is not a valid label name`,
+		},
+		{
+			name:        "built-in label name",
+			coords:      config.Coordinates{Lat: 46.24, Lon: 14.36, Labels: map[string]string{"location": "office"}},
+			wantProblem: `OpenWeather.CurrentWeatherData.Coords[0].Labels name "location" clashes with built-in label`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v config.Validator
+			v.Coords("OpenWeather.CurrentWeatherData", []config.Coordinates{tt.coords}, map[string]bool{"location": true})
+
+			err := v.Err()
+			if tt.wantProblem == "" {
+				require.NoError(t, err)
+				return
+			}
+			var validationErr *config.ValidationError
+			require.True(t, errors.As(err, &validationErr), err)
+			require.Equal(t, []string{tt.wantProblem}, validationErr.Problems)
+		})
+	}
+}
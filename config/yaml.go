@@ -0,0 +1,63 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ParseYAML decodes config from YAML as strictly as Parse does from JSON,
+// document is converted to JSON first, so both formats are decoded the same way.
+func ParseYAML(data []byte) (*Config, error) {
+	doc, err := decodeYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	jsonData, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(jsonData)
+}
+
+// decodeYAML decodes document into values that encoding/json would decode JSON into.
+func decodeYAML(data []byte) (interface{}, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return jsonValue(doc)
+}
+
+// jsonValue converts YAML mappings, which can have keys of any type, into JSON objects.
+func jsonValue(val interface{}) (interface{}, error) {
+	switch val := val.(type) {
+	case map[interface{}]interface{}:
+		obj := make(map[string]interface{}, len(val))
+		for k, v := range val {
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("mapping key %v is not a string", k)
+			}
+			converted, err := jsonValue(v)
+			if err != nil {
+				return nil, err
+			}
+			obj[key] = converted
+		}
+		return obj, nil
+	case []interface{}:
+		arr := make([]interface{}, len(val))
+		for i, v := range val {
+			converted, err := jsonValue(v)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = converted
+		}
+		return arr, nil
+	default:
+		return val, nil
+	}
+}
@@ -0,0 +1,50 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+	"gopkg.in/yaml.v2"
+)
+
+func TestParseYAML(t *testing.T) {
+	cfg, err := config.ParseYAML([]byte(`
+Netatmo:
+  StationsData:
+    Enabled: true
+    Interval: 5m
+OpenWeather:
+  CurrentWeatherData:
+    Enabled: true
+    Coords:
+      - Lat: 46.24
+        Lon: 14.36
+        Name: kranj
+        Labels:
+          site: office
+    Interval: 30s
+`))
+	require.NoError(t, err)
+	require.True(t, cfg.Netatmo.StationsData.Enabled)
+	require.Equal(t, config.Duration(5*time.Minute), cfg.Netatmo.StationsData.Interval)
+	require.Equal(t, []config.Coordinates{
+		{Lat: 46.24, Lon: 14.36, Name: "kranj", Labels: map[string]string{"site": "office"}},
+	}, cfg.OpenWeather.CurrentWeatherData.Coords)
+	require.Equal(t, config.Duration(30*time.Second), cfg.OpenWeather.CurrentWeatherData.Interval)
+
+	_, err = config.ParseYAML([]byte("Netatmo:\n  StationsData:\n    Intervall: 5m\n"))
+	require.EqualError(t, err, `json: unknown field "Intervall"`)
+}
+
+func TestDuration_YAML(t *testing.T) {
+	var d struct{ Interval config.Duration }
+	err := yaml.Unmarshal([]byte("interval: 1m30s"), &d)
+	require.NoError(t, err)
+	require.Equal(t, config.Duration(90*time.Second), d.Interval)
+
+	data, err := yaml.Marshal(d)
+	require.NoError(t, err)
+	require.Equal(t, "interval: 1m30s\n", string(data))
+}
@@ -0,0 +1,148 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ulexxander/weather-prometheus-exporters/series"
+)
+
+// APIError is implemented by errors that data source API responded with.
+type APIError interface {
+	error
+	APIErrorCode() string
+}
+
+const (
+	ClassHTTP   = "http"
+	ClassDecode = "decode"
+	ClassAPI    = "api"
+	ClassOther  = "other"
+)
+
+// Classify returns class of update error and its code if it is an API error.
+func Classify(err error) (class, code string) {
+	var apiErr APIError
+	if errors.As(err, &apiErr) {
+		return ClassAPI, apiErr.APIErrorCode()
+	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+		return ClassDecode, ""
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return ClassHTTP, ""
+	}
+	return ClassOther, ""
+}
+
+// Metrics reports outcome of periodic updates of a data source,
+// so stale data can be told apart from fresh one.
+type Metrics struct {
+	up          prometheus.Gauge
+	lastSuccess prometheus.Gauge
+	duration    prometheus.Histogram
+	errors      *prometheus.CounterVec
+}
+
+func NewMetrics(namespace, subsystem string) *Metrics {
+	return &Metrics{
+		up: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "up",
+			Help:      "Whether the last update succeeded.",
+		}),
+		lastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "last_update_success_timestamp_seconds",
+			Help:      "Unix time of the last successful update.",
+		}),
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "update_duration_seconds",
+			Help:      "Duration of updates.",
+		}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "update_errors_total",
+			Help:      "Errors occurred during updates by class and API error code.",
+		}, []string{"class", "code"}),
+	}
+}
+
+func (m *Metrics) forEach(f func(c prometheus.Collector)) {
+	f(m.up)
+	f(m.lastSuccess)
+	f(m.duration)
+	f(m.errors)
+}
+
+func (m *Metrics) Describe(d chan<- *prometheus.Desc) {
+	m.forEach(func(c prometheus.Collector) {
+		c.Describe(d)
+	})
+}
+
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.forEach(func(c prometheus.Collector) {
+		c.Collect(ch)
+	})
+}
+
+// Observe records outcome of update that started at start.
+// Update is successful only if there were no errors.
+func (m *Metrics) Observe(start time.Time, errs ...error) {
+	m.duration.Observe(time.Since(start).Seconds())
+
+	for _, err := range errs {
+		class, code := Classify(err)
+		m.errors.WithLabelValues(class, code).Inc()
+	}
+
+	if len(errs) > 0 {
+		m.up.Set(0)
+		return
+	}
+	m.up.Set(1)
+	m.lastSuccess.SetToCurrentTime()
+}
+
+// Update is outcome of update that queried several targets, like locations, areas or homes, one request each.
+type Update struct {
+	// Name is used in logs, like "One Call".
+	Name  string
+	Start time.Time
+	// Targets is how many targets were queried, Errs are errors of those that failed.
+	Targets int
+	Errs    []error
+}
+
+// Finish deletes series of tracker not set within staleGracePeriod, observes update and logs its outcome.
+// When every target failed there is nothing fresh to compare with, so series are kept as they are.
+func (m *Metrics) Finish(u Update, tracker *series.Tracker, staleGracePeriod time.Duration, log *log.Logger) {
+	allFailed := len(u.Errs) > 0 && len(u.Errs) >= u.Targets
+	if !allFailed {
+		if deleted := tracker.DeleteStale(u.Start.Add(-staleGracePeriod)); deleted > 0 {
+			log.Printf("Deleted %d stale series of %s", deleted, u.Name)
+		}
+	}
+
+	m.Observe(u.Start, u.Errs...)
+
+	duration := time.Since(u.Start)
+	if len(u.Errs) > 0 {
+		log.Printf("Updated %s with %d errors, took %s", u.Name, len(u.Errs), duration)
+		return
+	}
+	log.Printf("Updated %s successfully, took %s", u.Name, duration)
+}
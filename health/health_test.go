@@ -0,0 +1,134 @@
+package health_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/ulexxander/weather-prometheus-exporters/health"
+	"github.com/ulexxander/weather-prometheus-exporters/series"
+)
+
+type apiError struct {
+	code int
+}
+
+func (e *apiError) Error() string {
+	return "api error"
+}
+
+func (e *apiError) APIErrorCode() string {
+	return fmt.Sprint(e.code)
+}
+
+func TestClassify(t *testing.T) {
+	var dest struct{}
+	decodeErr := json.Unmarshal([]byte("{"), &dest)
+	_, httpErr := http.Get("http://127.0.0.1:0")
+
+	tt := []struct {
+		err   error
+		class string
+		code  string
+	}{
+		{fmt.Errorf("requesting: %w", &apiError{401}), health.ClassAPI, "401"},
+		{fmt.Errorf("unmarshaling: %w", decodeErr), health.ClassDecode, ""},
+		{fmt.Errorf("sending: %w", httpErr), health.ClassHTTP, ""},
+		{errors.New("something"), health.ClassOther, ""},
+	}
+	for _, tc := range tt {
+		class, code := health.Classify(tc.err)
+		require.Equal(t, tc.class, class, tc.err.Error())
+		require.Equal(t, tc.code, code, tc.err.Error())
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	metrics := health.NewMetrics("my", "source")
+
+	reg := prometheus.NewRegistry()
+	err := reg.Register(metrics)
+	require.NoError(t, err)
+
+	before := time.Now()
+	metrics.Observe(time.Now())
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	values := map[string]float64{}
+	for _, f := range families {
+		m := f.Metric[0]
+		switch {
+		case m.Gauge != nil:
+			values[f.GetName()] = m.Gauge.GetValue()
+		case m.Histogram != nil:
+			values[f.GetName()] = float64(m.Histogram.GetSampleCount())
+		}
+	}
+	require.Equal(t, 1.0, values["my_source_up"])
+	require.Equal(t, 1.0, values["my_source_update_duration_seconds"])
+	require.GreaterOrEqual(t, values["my_source_last_update_success_timestamp_seconds"], float64(before.Unix()))
+
+	metrics.Observe(time.Now(), &apiError{401}, &apiError{401}, errors.New("something"))
+
+	expected := `
+# HELP my_source_up Whether the last update succeeded.
+# TYPE my_source_up gauge
+my_source_up 0
+# HELP my_source_update_errors_total Errors occurred during updates by class and API error code.
+# TYPE my_source_update_errors_total counter
+my_source_update_errors_total{class="api",code="401"} 2
+my_source_update_errors_total{class="other",code=""} 1
+`
+	err = promtestutil.GatherAndCompare(reg, strings.NewReader(expected), "my_source_up", "my_source_update_errors_total")
+	require.NoError(t, err)
+}
+
+func TestMetrics_Finish(t *testing.T) {
+	metrics := health.NewMetrics("my", "source")
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "temperature"}, []string{"location"})
+	tracker := series.NewTracker()
+	logger := log.New(io.Discard, "", 0)
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(metrics)
+	requireUp := func(up int) {
+		expected := fmt.Sprintf(`
+# HELP my_source_up Whether the last update succeeded.
+# TYPE my_source_up gauge
+my_source_up %d
+`, up)
+		err := promtestutil.GatherAndCompare(reg, strings.NewReader(expected), "my_source_up")
+		require.NoError(t, err)
+	}
+
+	tracker.Set(vec, prometheus.Labels{"location": "kranj"}, 10)
+	tracker.Set(vec, prometheus.Labels{"location": "bled"}, 11)
+
+	// Every location failed, nothing is deleted.
+	start := time.Now()
+	errs := []error{errors.New("first"), errors.New("second")}
+	metrics.Finish(health.Update{Name: "test", Start: start, Targets: 2, Errs: errs}, tracker, 0, logger)
+	require.Equal(t, 2, promtestutil.CollectAndCount(vec))
+	requireUp(0)
+
+	// One location failed, series not set since start are deleted.
+	start = time.Now()
+	tracker.Set(vec, prometheus.Labels{"location": "kranj"}, 12)
+	metrics.Finish(health.Update{Name: "test", Start: start, Targets: 2, Errs: errs[:1]}, tracker, 0, logger)
+	require.Equal(t, 1, promtestutil.CollectAndCount(vec))
+
+	// Update without targets deletes everything.
+	start = time.Now()
+	metrics.Finish(health.Update{Name: "test", Start: start}, tracker, 0, logger)
+	require.Equal(t, 0, promtestutil.CollectAndCount(vec))
+	requireUp(1)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+	"github.com/ulexxander/weather-prometheus-exporters/source"
+)
+
+// job is a source job running in background.
+type job struct {
+	*source.Job
+	cancel context.CancelFunc
+}
+
+// exporter runs jobs of current config and applies changes of reloaded one.
+type exporter struct {
+	ctx  context.Context
+	log  *log.Logger
+	jobs map[string]*job
+	// secretFiles of current config are watched together with config file.
+	secretFiles []string
+
+	reloadSuccessful       prometheus.Gauge
+	reloadSuccessTimestamp prometheus.Gauge
+}
+
+func newExporter(ctx context.Context, log *log.Logger) *exporter {
+	return &exporter{
+		ctx:  ctx,
+		log:  log,
+		jobs: map[string]*job{},
+		reloadSuccessful: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "config_last_reload_successful",
+			Help: "Whether the last config reload succeeded.",
+		}),
+		reloadSuccessTimestamp: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "config_last_reload_success_timestamp_seconds",
+			Help: "Unix time of the last successful config reload.",
+		}),
+	}
+}
+
+func (e *exporter) Describe(d chan<- *prometheus.Desc) {
+	e.reloadSuccessful.Describe(d)
+	e.reloadSuccessTimestamp.Describe(d)
+}
+
+func (e *exporter) Collect(m chan<- prometheus.Metric) {
+	e.reloadSuccessful.Collect(m)
+	e.reloadSuccessTimestamp.Collect(m)
+}
+
+// load builds jobs of all sources and applies them, config is rejected without changing anything when jobs can not be built or registered.
+func (e *exporter) load(cfg *config.Config) error {
+	var jobs []*job
+	var secretFiles []string
+	for _, s := range source.All() {
+		sourceJobs, err := s.Jobs(cfg, e.log)
+		if err != nil {
+			return fmt.Errorf("%s: %w", s.Name(), err)
+		}
+		for _, j := range sourceJobs {
+			jobs = append(jobs, &job{Job: j})
+		}
+		if s.Enabled(cfg) {
+			secretFiles = append(secretFiles, s.SecretFiles(cfg)...)
+		}
+	}
+
+	if err := e.apply(jobs); err != nil {
+		return err
+	}
+	e.secretFiles = secretFiles
+	return nil
+}
+
+// apply starts new jobs, stops removed ones and restarts those whose config changed.
+// Collectors of new jobs are registered before anything is stopped,
+// when any of them is rejected running jobs are kept as they are.
+func (e *exporter) apply(jobs []*job) error {
+	next := map[string]*job{}
+	for _, j := range jobs {
+		next[j.Name] = j
+	}
+
+	var stopping []*job
+	for name, running := range e.jobs {
+		if j, ok := next[name]; ok && reflect.DeepEqual(j.Config, running.Config) {
+			next[name] = running
+			continue
+		}
+		stopping = append(stopping, running)
+	}
+	var starting []*job
+	for _, j := range jobs {
+		if next[j.Name] == j {
+			starting = append(starting, j)
+		}
+	}
+
+	// Restarted job registers the same metrics, so old collectors are unregistered first and registered back on failure.
+	for _, j := range stopping {
+		unregister(j)
+	}
+	for i, j := range starting {
+		if err := register(j); err != nil {
+			e.log.Printf("Error starting %s job: %s", j.Name, err)
+			for _, registered := range starting[:i] {
+				unregister(registered)
+			}
+			for _, j := range stopping {
+				if err := register(j); err != nil {
+					e.log.Printf("Error registering back %s job: %s", j.Name, err)
+				}
+			}
+			return err
+		}
+	}
+
+	for _, j := range stopping {
+		e.log.Printf("Stopping %s job", j.Name)
+		j.cancel()
+	}
+	for _, j := range starting {
+		ctx, cancel := context.WithCancel(e.ctx)
+		j.cancel = cancel
+		e.log.Printf("Starting %s job", j.Name)
+		go j.Collector.Run(ctx)
+	}
+
+	e.jobs = next
+	return nil
+}
+
+func register(j *job) error {
+	if err := j.Registerer.Register(j.Collector); err != nil {
+		return fmt.Errorf("registering %s collector: %w", j.Name, err)
+	}
+	if err := j.Registerer.Register(j.Health); err != nil {
+		j.Registerer.Unregister(j.Collector)
+		return fmt.Errorf("registering %s health collector: %w", j.Name, err)
+	}
+	return nil
+}
+
+// unregister removes collectors of job, so its series disappear.
+func unregister(j *job) {
+	j.Registerer.Unregister(j.Collector)
+	j.Registerer.Unregister(j.Health)
+}
+
+// reload reads config file again and applies it, running config is kept when new one is invalid.
+func (e *exporter) reload(path string) {
+	e.log.Println("Reloading config from", path)
+	config, err := loadConfig(path, true)
+	if err == nil {
+		err = e.load(config)
+	}
+	if err != nil {
+		e.log.Println("Error reloading config:", err)
+		e.reloadSuccessful.Set(0)
+		return
+	}
+
+	e.reloadSuccessful.Set(1)
+	e.reloadSuccessTimestamp.SetToCurrentTime()
+	e.log.Print("Reloaded config successfully")
+}
+
+// watch reloads config on SIGHUP, and when modification time of config or secret files changes if pollInterval is not zero.
+func (e *exporter) watch(path string, pollInterval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var poll <-chan time.Time
+	var modTimes string
+	if pollInterval > 0 {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+		modTimes = filesModTimes(append([]string{path}, e.secretFiles...))
+	}
+
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-hup:
+			e.reload(path)
+		case <-poll:
+			latest := filesModTimes(append([]string{path}, e.secretFiles...))
+			if latest == modTimes {
+				continue
+			}
+			e.reload(path)
+			// Secret files could have changed with reload, so times are taken again.
+			modTimes = filesModTimes(append([]string{path}, e.secretFiles...))
+		}
+	}
+}
+
+// filesModTimes describes modification times of files,
+// those that can not be read have zero time, so they are reloaded once they appear again.
+func filesModTimes(paths []string) string {
+	var b strings.Builder
+	for _, path := range paths {
+		var modTime time.Time
+		if info, err := os.Stat(path); err == nil {
+			modTime = info.ModTime()
+		}
+		fmt.Fprintf(&b, "%s=%d\n", path, modTime.UnixNano())
+	}
+	return b.String()
+}
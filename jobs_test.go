@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"github.com/ulexxander/weather-prometheus-exporters/health"
+	"github.com/ulexxander/weather-prometheus-exporters/source"
+)
+
+// testCollector reports when it is started and stopped.
+type testCollector struct {
+	desc    *prometheus.Desc
+	started chan struct{}
+	stopped chan struct{}
+}
+
+func newTestCollector(desc *prometheus.Desc) *testCollector {
+	return &testCollector{
+		desc:    desc,
+		started: make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+func (c *testCollector) Describe(d chan<- *prometheus.Desc) {
+	d <- c.desc
+}
+
+func (c *testCollector) Collect(m chan<- prometheus.Metric) {
+	m <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, 1)
+}
+
+func (c *testCollector) Run(ctx context.Context) {
+	close(c.started)
+	<-ctx.Done()
+	close(c.stopped)
+}
+
+func TestExporter_ApplyRegisterError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	exporter := newExporter(ctx, log.New(io.Discard, "", 0))
+	reg := prometheus.NewRegistry()
+
+	newJob := func(name, config string, collector *testCollector) *job {
+		return &job{Job: &source.Job{
+			Name:       name,
+			Config:     config,
+			Registerer: reg,
+			Collector:  collector,
+			Health:     health.NewMetrics("test", name),
+		}}
+	}
+
+	desc := prometheus.NewDesc("test_value", "Test value.", nil, nil)
+	running := newTestCollector(desc)
+	err := exporter.apply([]*job{newJob("first", "v1", running)})
+	require.NoError(t, err)
+	select {
+	case <-running.started:
+	case <-time.After(time.Second):
+		require.Fail(t, "job did not start")
+	}
+
+	// Changed first job would replace running one, but second job is rejected by registry.
+	changed := newTestCollector(desc)
+	invalid := newTestCollector(prometheus.NewInvalidDesc(errors.New("invalid")))
+	err = exporter.apply([]*job{newJob("first", "v2", changed), newJob("second", "v1", invalid)})
+	require.Error(t, err)
+
+	select {
+	case <-running.stopped:
+		require.Fail(t, "running job was stopped")
+	case <-changed.started:
+		require.Fail(t, "changed job was started")
+	case <-time.After(100 * time.Millisecond):
+	}
+	require.Len(t, exporter.jobs, 1)
+	require.Equal(t, "v1", exporter.jobs["first"].Config)
+
+	// Running job is still registered, so its series are still exported.
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	var names []string
+	for _, family := range families {
+		names = append(names, family.GetName())
+	}
+	require.Contains(t, names, "test_value")
+
+	// Without invalid job the change is applied.
+	err = exporter.apply([]*job{newJob("first", "v2", changed)})
+	require.NoError(t, err)
+	select {
+	case <-running.stopped:
+	case <-time.After(time.Second):
+		require.Fail(t, "running job was not stopped")
+	}
+	select {
+	case <-changed.started:
+	case <-time.After(time.Second):
+		require.Fail(t, "changed job did not start")
+	}
+}
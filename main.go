@@ -2,13 +2,14 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -16,14 +17,15 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/ulexxander/weather-prometheus-exporters/config"
-	"github.com/ulexxander/weather-prometheus-exporters/netatmo"
-	"github.com/ulexxander/weather-prometheus-exporters/openweather"
+	"github.com/ulexxander/weather-prometheus-exporters/source"
+	_ "github.com/ulexxander/weather-prometheus-exporters/source/all"
 )
 
 var (
-	flagAddr    = flag.String("addr", ":80", "Address to serve HTTP metrics on")
-	flagConfig  = flag.String("config", "./config.json", "Config file location")
-	flagEnvFile = flag.String("env-file", "", "Environment variables file to load (dotenv)")
+	flagAddr               = flag.String("addr", ":80", "Address to serve HTTP metrics on")
+	flagConfig             = flag.String("config", "./config.json", "Config file location, JSON or YAML (.yaml, .yml)")
+	flagConfigPollInterval = flag.Duration("config-poll-interval", 0, "How often config and secret files are checked for modification to reload them, disabled when zero (SIGHUP always reloads)")
+	flagEnvFile            = flag.String("env-file", "", "Environment variables file to load (dotenv)")
 )
 
 func main() {
@@ -47,22 +49,65 @@ func run(log *log.Logger) error {
 		}
 	}
 
+	cmd := flag.Arg(0)
+
 	log.Println("Reading config file from", *flagConfig)
-	configJSON, err := os.ReadFile(*flagConfig)
+	// Commands other than serving need only Netatmo credentials, which they check themselves.
+	config, err := loadConfig(*flagConfig, cmd == "" || cmd == "check-config")
+	if err != nil {
+		return err
+	}
+
+	switch cmd {
+	case "":
+		return serve(ctx, config, log)
+	case "check-config":
+		log.Println("Config is valid")
+		return nil
+	case "authorize":
+		return authorize(ctx, &config.Netatmo, flag.Args()[1:], log)
+	case "backfill":
+		return backfill(&config.Netatmo, flag.Args()[1:], log)
+	default:
+		return fmt.Errorf("unknown command: %s", cmd)
+	}
+}
+
+// loadConfig reads config in JSON or YAML format by file extension and validates it, all problems including missing credentials are reported together.
+func loadConfig(path string, checkCredentials bool) (*config.Config, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("reading config file: %w", err)
+		return nil, fmt.Errorf("reading config file: %w", err)
 	}
-	var config config.Config
-	if err := json.Unmarshal(configJSON, &config); err != nil {
-		return fmt.Errorf("unmarshaling config: %w", err)
+	ext := filepath.Ext(path)
+	cfg, err := config.Load(data, ext == ".yaml" || ext == ".yml", os.LookupEnv)
+	if err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
 	}
 
-	if err := runOpenWeather(ctx, &config.OpenWeather, log); err != nil {
-		return fmt.Errorf("running OpenWeather: %w", err)
+	problems := validationProblems(cfg)
+	if checkCredentials {
+		if err := credentialsError(cfg); err != nil {
+			problems = append(problems, err.Error())
+		}
 	}
-	if err := runNetatmo(ctx, &config.Netatmo, log); err != nil {
-		return fmt.Errorf("running Netatmo: %w", err)
+	if len(problems) > 0 {
+		return nil, &config.ValidationError{Problems: problems}
 	}
+	return cfg, nil
+}
+
+func serve(ctx context.Context, config *config.Config, log *log.Logger) error {
+	exporter := newExporter(ctx, log)
+	if err := exporter.load(config); err != nil {
+		return err
+	}
+	exporter.reloadSuccessful.Set(1)
+	exporter.reloadSuccessTimestamp.SetToCurrentTime()
+	if err := prometheus.Register(exporter); err != nil {
+		return fmt.Errorf("registering config reload collector: %w", err)
+	}
+	go exporter.watch(*flagConfig, *flagConfigPollInterval)
 
 	log.Println("Starting HTTP server on", *flagAddr)
 	server := http.Server{
@@ -88,76 +133,34 @@ func run(log *log.Logger) error {
 	return nil
 }
 
-type env struct {
-	missingKeys []string
-}
-
-func (e *env) Get(key string) string {
-	val := os.Getenv(key)
-	if val == "" {
-		e.missingKeys = append(e.missingKeys, key)
-	}
-	return val
-}
-
-func (e *env) Error() error {
-	if len(e.missingKeys) == 0 {
-		return nil
+// validationProblems collects problems that sources report about their sections of config.
+func validationProblems(cfg *config.Config) []string {
+	var problems []string
+	for _, s := range source.All() {
+		err := s.Validate(cfg)
+		var validationErr *config.ValidationError
+		if errors.As(err, &validationErr) {
+			problems = append(problems, validationErr.Problems...)
+		} else if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", s.Name(), err))
+		}
 	}
-	missing := strings.Join(e.missingKeys, ", ")
-	return fmt.Errorf("missing environment variables: %s", missing)
+	return problems
 }
 
-func runOpenWeather(ctx context.Context, config *config.OpenWeather, log *log.Logger) error {
-	if !config.CurrentWeatherData.Enabled {
-		log.Print("OpenWeather Current Weather Data is disabled")
-		return nil
-	}
-
-	var env env
-	appID := env.Get("OPEN_WEATHER_APP_ID")
-	if err := env.Error(); err != nil {
-		return err
-	}
-
-	client := openweather.NewClient(appID)
-
-	cwd := openweather.NewCurrentWeatherData(client, &config.CurrentWeatherData, log)
-	if err := prometheus.Register(cwd); err != nil {
-		return fmt.Errorf("registering Current Weather Data collector: %w", err)
+// credentialsError reports missing credentials of all enabled sources.
+func credentialsError(cfg *config.Config) error {
+	var errs []string
+	for _, s := range source.All() {
+		if !s.Enabled(cfg) {
+			continue
+		}
+		if err := s.CheckCredentials(cfg); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", s.Name(), err))
+		}
 	}
-
-	log.Print("Starting OpenWeather Current Weather Data job")
-	go cwd.Run(ctx)
-
-	return nil
-}
-
-func runNetatmo(ctx context.Context, config *config.Netatmo, log *log.Logger) error {
-	if !config.StationsData.Enabled {
-		log.Print("Netatmo Stations Data is disabled")
+	if len(errs) == 0 {
 		return nil
 	}
-
-	var env env
-	clientID := env.Get("NETATMO_CLIENT_ID")
-	clientSecret := env.Get("NETATMO_CLIENT_SECRET")
-	username := env.Get("NETATMO_USERNAME")
-	password := env.Get("NETATMO_PASSWORD")
-	if err := env.Error(); err != nil {
-		return err
-	}
-
-	oauth := netatmo.NewOAuth(clientID, clientSecret, username, password)
-	client := netatmo.NewClient(oauth)
-
-	stationsData := netatmo.NewStationsData(client, &config.StationsData, log)
-	if err := prometheus.Register(stationsData); err != nil {
-		return fmt.Errorf("registering Stations Data collector: %w", err)
-	}
-
-	log.Print("Starting Stations Data update job")
-	go stationsData.Run(ctx)
-
-	return nil
+	return errors.New(strings.Join(errs, "; "))
 }
@@ -0,0 +1,98 @@
+package netatmo
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+	"github.com/ulexxander/weather-prometheus-exporters/source"
+)
+
+// Credentials of account, Username and Password are resolved only when account has no token file.
+type Credentials struct {
+	ClientID     string
+	ClientSecret string
+	Username     string
+	Password     string
+}
+
+// ResolveCredentials reads credentials of account, by default from environment variables with its EnvPrefix.
+func ResolveCredentials(account *config.NetatmoAccount) (*Credentials, error) {
+	var secrets source.Secrets
+	prefix := envPrefix(account)
+	creds := Credentials{
+		ClientID:     secrets.Get("ClientID", account.ClientID, prefix+"CLIENT_ID"),
+		ClientSecret: secrets.Get("ClientSecret", account.ClientSecret, prefix+"CLIENT_SECRET"),
+	}
+	if account.TokenFile == "" {
+		creds.Username = secrets.Get("Username", account.Username, prefix+"USERNAME")
+		creds.Password = secrets.Get("Password", account.Password, prefix+"PASSWORD")
+	}
+	if err := secrets.Error(); err != nil {
+		return nil, err
+	}
+	return &creds, nil
+}
+
+func envPrefix(account *config.NetatmoAccount) string {
+	if account.EnvPrefix != "" {
+		return account.EnvPrefix
+	}
+	return "NETATMO_"
+}
+
+// NewAccountClient returns client of account authorized by its token file, or by username and password without it.
+func NewAccountClient(account *config.NetatmoAccount, creds *Credentials, scope string) *Client {
+	var oauth OAuth
+	if account.TokenFile != "" {
+		oauth = NewStoredOAuth(creds.ClientID, creds.ClientSecret, NewFileTokenStore(account.TokenFile))
+	} else {
+		oauth = NewOAuth(creds.ClientID, creds.ClientSecret, creds.Username, creds.Password)
+	}
+	client := NewClient(NewCachingOAuth(oauth))
+	client.Scope = scope
+	return client
+}
+
+// FindAccount returns account by name, name can be empty when only one is configured.
+func FindAccount(config *config.Netatmo, name string) (*config.NetatmoAccount, error) {
+	accounts := config.AllAccounts()
+	if name == "" {
+		if len(accounts) > 1 {
+			return nil, errors.New("multiple Netatmo accounts are configured, choose one with -account flag")
+		}
+		return &accounts[0], nil
+	}
+	for i := range accounts {
+		if accounts[i].Name == name {
+			return &accounts[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown Netatmo account %q", name)
+}
+
+// Scope returns scopes needed by enabled collectors.
+// Stations are read when nothing is enabled, so "authorize" command grants access to them by default.
+func Scope(config *config.Netatmo) string {
+	var scopes []string
+	if config.StationsData.Enabled || config.PublicData.Enabled {
+		scopes = append(scopes, ScopeReadStation)
+	}
+	if config.HomeCoachsData.Enabled {
+		scopes = append(scopes, ScopeReadHomecoach)
+	}
+	if config.HomeStatus.Enabled {
+		scopes = append(scopes, ScopeReadThermostat)
+	}
+	if len(scopes) == 0 {
+		return ScopeReadStation
+	}
+	return strings.Join(scopes, " ")
+}
+
+// accountLogger prefixes messages with account name, so updates of different accounts are distinguishable.
+func accountLogger(logger *log.Logger, account *config.NetatmoAccount) *log.Logger {
+	return log.New(logger.Writer(), fmt.Sprintf("%s[%s] ", logger.Prefix(), account.Name), logger.Flags())
+}
@@ -0,0 +1,201 @@
+package netatmo
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// measureMetric maps measurement type of /getmeasure to gauge exported by StationsData.
+type measureMetric struct {
+	measureType string
+	subsystem   string
+	name        string
+}
+
+// measureMetrics are measurements that can be backfilled by device type.
+var measureMetrics = map[string][]measureMetric{
+	DeviceTypeIndoor: {
+		{measureType: "Temperature", subsystem: "indoor_module", name: "temperature"},
+		{measureType: "CO2", subsystem: "indoor_module", name: "co2"},
+		{measureType: "Humidity", subsystem: "indoor_module", name: "humidity"},
+		{measureType: "Noise", subsystem: "indoor_module", name: "noise"},
+		{measureType: "Pressure", subsystem: "indoor_module", name: "pressure"},
+	},
+	DeviceTypeOutdoor: {
+		{measureType: "Temperature", subsystem: "outdoor_module", name: "temperature"},
+		{measureType: "Humidity", subsystem: "outdoor_module", name: "humidity"},
+	},
+	DeviceTypeWind: {
+		{measureType: "WindStrength", subsystem: "wind_module", name: "wind_strength"},
+		{measureType: "WindAngle", subsystem: "wind_module", name: "wind_angle"},
+		{measureType: "GustStrength", subsystem: "wind_module", name: "gust_strength"},
+		{measureType: "GustAngle", subsystem: "wind_module", name: "gust_angle"},
+	},
+	// Rain is the sum over the step at scales coarser than max.
+	DeviceTypeRain: {
+		{measureType: "Rain", subsystem: "rain_module", name: "rain"},
+	},
+	DeviceTypeIndoorExtra: {
+		{measureType: "Temperature", subsystem: "indoor_extra_module", name: "temperature"},
+		{measureType: "Humidity", subsystem: "indoor_extra_module", name: "humidity"},
+		{measureType: "CO2", subsystem: "indoor_extra_module", name: "co2"},
+	},
+}
+
+// Backfill collects historical measurements of all stations and their modules
+// under the same names and labels that StationsData exports them,
+// so they can be imported into Prometheus to fill gaps.
+type Backfill struct {
+	client *Client
+	log    *log.Logger
+	// Scale is time between measurements, like Scale30Minutes.
+	Scale string
+	// ConstLabels are added to every series, like account label of exported ones.
+	ConstLabels prometheus.Labels
+}
+
+func NewBackfill(client *Client, scale string, log *log.Logger) *Backfill {
+	return &Backfill{
+		client: client,
+		log:    log,
+		Scale:  scale,
+	}
+}
+
+// MetricFamilies returns measurements from given time range as gauges with sample timestamps, ordered by name.
+func (b *Backfill) MetricFamilies(from, to time.Time) ([]*dto.MetricFamily, error) {
+	families := metricFamilies{}
+
+	stationsData, err := b.client.StationsData()
+	if err != nil {
+		return nil, fmt.Errorf("fetching stations data: %w", err)
+	}
+
+	for _, device := range stationsData.Body.Devices {
+		stationLabels := prometheus.Labels{
+			"home_id":      device.HomeID,
+			"home_name":    device.HomeName,
+			"id":           device.ID,
+			"type":         device.Type,
+			"station_name": device.StationName,
+		}
+		if err := b.measure(families, device.ID, "", device.Type, stationLabels, from, to); err != nil {
+			return nil, fmt.Errorf("backfilling device %s: %w", device.ID, err)
+		}
+		b.log.Printf("Backfilled %s device %s (%s)", device.Type, device.StationName, device.ID)
+
+		for _, module := range device.Modules {
+			if _, ok := measureMetrics[module.Type]; !ok {
+				b.log.Printf("Unsupported module type: %s", module.Type)
+				continue
+			}
+			moduleLabels := prometheus.Labels{
+				"home_id":     device.HomeID,
+				"home_name":   device.HomeName,
+				"id":          module.ID,
+				"type":        module.Type,
+				"module_name": module.ModuleName,
+			}
+			if err := b.measure(families, device.ID, module.ID, module.Type, moduleLabels, from, to); err != nil {
+				return nil, fmt.Errorf("backfilling module %s: %w", module.ID, err)
+			}
+			b.log.Printf("Backfilled %s module %s (%s)", module.Type, module.ModuleName, module.ID)
+		}
+	}
+
+	return families.sorted(), nil
+}
+
+func (b *Backfill) measure(families metricFamilies, deviceID, moduleID, deviceType string, labels prometheus.Labels, from, to time.Time) error {
+	metrics := measureMetrics[deviceType]
+	if len(metrics) == 0 {
+		return nil
+	}
+	types := make([]string, len(metrics))
+	for i, m := range metrics {
+		types[i] = m.measureType
+	}
+
+	measurements, err := b.client.MeasureAll(&MeasureRequest{
+		DeviceID:  deviceID,
+		ModuleID:  moduleID,
+		Scale:     b.Scale,
+		Types:     types,
+		DateBegin: from,
+		DateEnd:   to,
+	})
+	if err != nil {
+		return err
+	}
+
+	for name, value := range b.ConstLabels {
+		labels[name] = value
+	}
+	labelPairs := makeLabelPairs(labels)
+	for i, m := range metrics {
+		family := families.get(prometheus.BuildFQName("netatmo", m.subsystem, m.name))
+		for _, measurement := range measurements {
+			if i >= len(measurement.Values) || measurement.Values[i] == nil {
+				continue
+			}
+			value := *measurement.Values[i]
+			timestampMs := measurement.Time.UnixNano() / int64(time.Millisecond)
+			family.Metric = append(family.Metric, &dto.Metric{
+				Label:       labelPairs,
+				Gauge:       &dto.Gauge{Value: &value},
+				TimestampMs: &timestampMs,
+			})
+		}
+	}
+	return nil
+}
+
+// metricFamilies are gauge families by name.
+type metricFamilies map[string]*dto.MetricFamily
+
+func (mf metricFamilies) get(name string) *dto.MetricFamily {
+	family, ok := mf[name]
+	if !ok {
+		family = &dto.MetricFamily{
+			Name: &name,
+			Type: dto.MetricType_GAUGE.Enum(),
+		}
+		mf[name] = family
+	}
+	return family
+}
+
+// sorted returns families ordered by name, leaving out those without any measurements.
+func (mf metricFamilies) sorted() []*dto.MetricFamily {
+	families := make([]*dto.MetricFamily, 0, len(mf))
+	for _, family := range mf {
+		if len(family.Metric) > 0 {
+			families = append(families, family)
+		}
+	}
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].GetName() < families[j].GetName()
+	})
+	return families
+}
+
+// makeLabelPairs returns label pairs ordered by name.
+func makeLabelPairs(labels prometheus.Labels) []*dto.LabelPair {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]*dto.LabelPair, len(names))
+	for i, name := range names {
+		name, value := name, labels[name]
+		pairs[i] = &dto.LabelPair{Name: &name, Value: &value}
+	}
+	return pairs
+}
@@ -0,0 +1,196 @@
+package netatmo_test
+
+import (
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	"github.com/ulexxander/weather-prometheus-exporters/netatmo"
+	"github.com/ulexxander/weather-prometheus-exporters/testutil"
+)
+
+func TestClient_MeasureAll(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := netatmo.NewClient(oauth)
+	client.URL = server.URL
+
+	type requestResult struct {
+		measurements []netatmo.Measurement
+		err          error
+	}
+	resultChan := make(chan requestResult, 1)
+	go func() {
+		measurements, err := client.MeasureAll(&netatmo.MeasureRequest{
+			DeviceID:  "70:ee:50:80:26:fa",
+			ModuleID:  "02:00:00:7f:e6:96",
+			Scale:     netatmo.Scale30Minutes,
+			Types:     []string{"Temperature", "Humidity"},
+			DateBegin: time.Unix(1651400000, 0),
+			DateEnd:   time.Unix(1651500000, 0),
+			Limit:     2,
+		})
+		resultChan <- requestResult{measurements, err}
+	}()
+
+	receiveRequest := func() *http.Request {
+		select {
+		case r := <-handler.Requests:
+			return r
+		case <-time.After(time.Second):
+			require.FailNow(t, "request did not arrived")
+		}
+		return nil
+	}
+
+	// First page is full, so the next one starts after its last measurement.
+	r := receiveRequest()
+	require.Equal(t, "/getmeasure", r.URL.Path)
+	query := r.URL.Query()
+	require.Equal(t, "70:ee:50:80:26:fa", query.Get("device_id"))
+	require.Equal(t, "02:00:00:7f:e6:96", query.Get("module_id"))
+	require.Equal(t, "30min", query.Get("scale"))
+	require.Equal(t, "Temperature,Humidity", query.Get("type"))
+	require.Equal(t, "1651400000", query.Get("date_begin"))
+	require.Equal(t, "1651500000", query.Get("date_end"))
+	require.Equal(t, "2", query.Get("limit"))
+	require.Equal(t, "false", query.Get("optimize"))
+	handler.Responses <- []byte(`{"body":{"1651401800":[17.4,55],"1651403600":[17.1,57]},"status":"ok"}`)
+
+	r = receiveRequest()
+	require.Equal(t, "1651403601", r.URL.Query().Get("date_begin"))
+	handler.Responses <- []byte(`{"body":{"1651405400":[16.8,null]},"status":"ok"}`)
+
+	var result requestResult
+	select {
+	case result = <-resultChan:
+	case <-time.After(time.Second):
+		require.Fail(t, "result did not arrived")
+	}
+
+	require.NoError(t, result.err)
+	require.Equal(t, []netatmo.Measurement{
+		{Time: time.Unix(1651401800, 0), Values: []*float64{fptr(17.4), fptr(55)}},
+		{Time: time.Unix(1651403600, 0), Values: []*float64{fptr(17.1), fptr(57)}},
+		{Time: time.Unix(1651405400, 0), Values: []*float64{fptr(16.8), nil}},
+	}, result.measurements)
+}
+
+func TestBackfill(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := netatmo.NewClient(oauth)
+	client.URL = server.URL
+
+	type backfillResult struct {
+		families []*dto.MetricFamily
+		err      error
+	}
+	resultChan := make(chan backfillResult, 1)
+	backfill := netatmo.NewBackfill(client, netatmo.Scale1Hour, log.Default())
+	backfill.ConstLabels = prometheus.Labels{"account": "home"}
+	go func() {
+		families, err := backfill.MetricFamilies(time.Unix(1651400000, 0), time.Unix(1651500000, 0))
+		resultChan <- backfillResult{families, err}
+	}()
+
+	receiveRequest := func() *http.Request {
+		select {
+		case r := <-handler.Requests:
+			return r
+		case <-time.After(time.Second):
+			require.FailNow(t, "request did not arrived")
+		}
+		return nil
+	}
+
+	r := receiveRequest()
+	require.Equal(t, "/getstationsdata", r.URL.Path)
+	handler.Responses <- []byte(`{
+		"body": {
+			"devices": [
+				{
+					"_id": "70:ee:50:80:26:fa",
+					"type": "NAMain",
+					"station_name": "My home (Indoor)",
+					"home_id": "61b646afb535277ce721d1a4",
+					"home_name": "My home",
+					"modules": [
+						{ "_id": "02:00:00:7f:e6:96", "type": "NAModule1", "module_name": "Zunanji modul" },
+						{ "_id": "09:00:00:00:00:01", "type": "NAModule9", "module_name": "Unknown" }
+					]
+				}
+			]
+		},
+		"status": "ok"
+	}`)
+
+	r = receiveRequest()
+	require.Equal(t, "/getmeasure", r.URL.Path)
+	require.Equal(t, "", r.URL.Query().Get("module_id"))
+	require.Equal(t, "Temperature,CO2,Humidity,Noise,Pressure", r.URL.Query().Get("type"))
+	handler.Responses <- []byte(`{"body":{"1651402800":[20.7,418,45,43,1010.8]},"status":"ok"}`)
+
+	r = receiveRequest()
+	require.Equal(t, "02:00:00:7f:e6:96", r.URL.Query().Get("module_id"))
+	require.Equal(t, "Temperature,Humidity", r.URL.Query().Get("type"))
+	handler.Responses <- []byte(`{"body":{"1651402800":[17.4,null]},"status":"ok"}`)
+
+	var result backfillResult
+	select {
+	case result = <-resultChan:
+	case <-time.After(time.Second):
+		require.Fail(t, "result did not arrived")
+	}
+	require.NoError(t, result.err)
+
+	stationLabels := []*dto.LabelPair{
+		{Name: sptr("account"), Value: sptr("home")},
+		{Name: sptr("home_id"), Value: sptr("61b646afb535277ce721d1a4")},
+		{Name: sptr("home_name"), Value: sptr("My home")},
+		{Name: sptr("id"), Value: sptr("70:ee:50:80:26:fa")},
+		{Name: sptr("station_name"), Value: sptr("My home (Indoor)")},
+		{Name: sptr("type"), Value: sptr("NAMain")},
+	}
+	outdoorLabels := []*dto.LabelPair{
+		{Name: sptr("account"), Value: sptr("home")},
+		{Name: sptr("home_id"), Value: sptr("61b646afb535277ce721d1a4")},
+		{Name: sptr("home_name"), Value: sptr("My home")},
+		{Name: sptr("id"), Value: sptr("02:00:00:7f:e6:96")},
+		{Name: sptr("module_name"), Value: sptr("Zunanji modul")},
+		{Name: sptr("type"), Value: sptr("NAModule1")},
+	}
+	timestampMs := int64(1651402800000)
+	family := func(name string, labels []*dto.LabelPair, values ...float64) *dto.MetricFamily {
+		family := &dto.MetricFamily{
+			Name: sptr(name),
+			Type: dto.MetricType_GAUGE.Enum(),
+		}
+		for _, value := range values {
+			family.Metric = append(family.Metric, &dto.Metric{
+				Label:       labels,
+				Gauge:       &dto.Gauge{Value: fptr(value)},
+				TimestampMs: &timestampMs,
+			})
+		}
+		return family
+	}
+
+	require.Equal(t, []*dto.MetricFamily{
+		family("netatmo_indoor_module_co2", stationLabels, 418),
+		family("netatmo_indoor_module_humidity", stationLabels, 45),
+		family("netatmo_indoor_module_noise", stationLabels, 43),
+		family("netatmo_indoor_module_pressure", stationLabels, 1010.8),
+		family("netatmo_indoor_module_temperature", stationLabels, 20.7),
+		family("netatmo_outdoor_module_temperature", outdoorLabels, 17.4),
+	}, result.families)
+}
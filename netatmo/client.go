@@ -5,22 +5,40 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 )
 
 type Client struct {
 	URL   string
 	OAuth OAuth
+	// Scope of access token used for all requests, scopes are separated by space.
+	// Single token covers all of them, so it is not refreshed for every scope separately.
+	Scope string
 }
 
 const DefaultURL = "https://api.netatmo.com/api"
 
+const (
+	ScopeReadStation    = "read_station"
+	ScopeReadHomecoach  = "read_homecoach"
+	ScopeReadThermostat = "read_thermostat"
+)
+
 func NewClient(oauth OAuth) *Client {
 	return &Client{
 		URL:   DefaultURL,
 		OAuth: oauth,
+		Scope: ScopeReadStation,
 	}
 }
 
+// Error codes of rejected access token, it is renewed and request is retried once when API responds with them.
+const (
+	ErrorCodeInvalidAccessToken = 2
+	ErrorCodeAccessTokenExpired = 3
+)
+
 type Error struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
@@ -30,6 +48,10 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("netatmo: code=%d msg=%s", e.Code, e.Message)
 }
 
+func (e *Error) APIErrorCode() string {
+	return strconv.Itoa(e.Code)
+}
+
 type ErrorResponse struct {
 	Error *Error `json:"error"`
 }
@@ -57,9 +79,11 @@ type StationsDataResponse struct {
 }
 
 const (
-	DeviceTypeIndoor  = "NAMain"
-	DeviceTypeOutdoor = "NAModule1"
-	DeviceTypeWind    = "NAModule2"
+	DeviceTypeIndoor      = "NAMain"
+	DeviceTypeOutdoor     = "NAModule1"
+	DeviceTypeWind        = "NAModule2"
+	DeviceTypeRain        = "NAModule3"
+	DeviceTypeIndoorExtra = "NAModule4"
 )
 
 type Device struct {
@@ -105,8 +129,9 @@ type Module struct {
 	BatteryVp      int      `json:"battery_vp"`
 	DashboardData  struct {
 		TimeUtc int `json:"time_utc"`
-		OutdoorModuleData
+		IndoorExtraModuleData
 		WindModuleData
+		RainModuleData
 	} `json:"dashboard_data"`
 }
 
@@ -121,6 +146,7 @@ type IndoorModuleData struct {
 	MaxTemp          float64 `json:"max_temp"`
 	DateMaxTemp      int     `json:"date_max_temp"`
 	DateMinTemp      int     `json:"date_min_temp"`
+	TempTrend        string  `json:"temp_trend"`
 	PressureTrend    string  `json:"pressure_trend"`
 }
 
@@ -134,6 +160,20 @@ type OutdoorModuleData struct {
 	TempTrend   string  `json:"temp_trend"`
 }
 
+// IndoorExtraModuleData has the same fields as OutdoorModuleData and CO2 in addition.
+// OutdoorModuleData is embedded instead of repeating its fields,
+// because fields with the same JSON names on the same level would not be decoded.
+type IndoorExtraModuleData struct {
+	OutdoorModuleData
+	CO2 float64 `json:"CO2"`
+}
+
+type RainModuleData struct {
+	Rain      float64 `json:"Rain"`
+	SumRain1  float64 `json:"sum_rain_1"`
+	SumRain24 float64 `json:"sum_rain_24"`
+}
+
 type WindModuleData struct {
 	WindStrength   float64 `json:"WindStrength"`
 	WindAngle      float64 `json:"WindAngle"`
@@ -144,9 +184,15 @@ type WindModuleData struct {
 	DateMaxWindStr int     `json:"date_max_wind_str"`
 }
 
+const (
+	TrendUp     = "up"
+	TrendDown   = "down"
+	TrendStable = "stable"
+)
+
 func (c *Client) StationsData() (*StationsDataResponse, error) {
 	var res StationsDataResponse
-	if err := c.Request("/getstationsdata", &res); err != nil {
+	if err := c.Request("/getstationsdata", nil, &res); err != nil {
 		return nil, fmt.Errorf("requesting /getstationsdata: %w", err)
 	}
 	if res.Error != nil {
@@ -155,13 +201,241 @@ func (c *Client) StationsData() (*StationsDataResponse, error) {
 	return &res, nil
 }
 
-func (c *Client) Request(endpoint string, dest interface{}) error {
-	token, err := c.OAuth.Token("read_station")
+const DeviceTypeHomeCoach = "NHC"
+
+type HomeCoachsDataResponse struct {
+	ErrorResponse
+	Body struct {
+		Devices []HomeCoach `json:"devices"`
+	} `json:"body"`
+	Status     string  `json:"status"`
+	TimeExec   float64 `json:"time_exec"`
+	TimeServer int     `json:"time_server"`
+}
+
+type HomeCoach struct {
+	ID             string `json:"_id"`
+	Type           string `json:"type"`
+	Name           string `json:"name"`
+	StationName    string `json:"station_name"`
+	Firmware       int    `json:"firmware"`
+	WifiStatus     int    `json:"wifi_status"`
+	Reachable      bool   `json:"reachable"`
+	Co2Calibrating bool   `json:"co2_calibrating"`
+	DashboardData  struct {
+		TimeUtc int `json:"time_utc"`
+		IndoorModuleData
+		// HealthIdx is from 0 (healthy) to 4 (unhealthy).
+		HealthIdx float64 `json:"health_idx"`
+	} `json:"dashboard_data"`
+}
+
+func (c *Client) HomeCoachsData() (*HomeCoachsDataResponse, error) {
+	var res HomeCoachsDataResponse
+	if err := c.Request("/gethomecoachsdata", nil, &res); err != nil {
+		return nil, fmt.Errorf("requesting /gethomecoachsdata: %w", err)
+	}
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	return &res, nil
+}
+
+type HomesDataResponse struct {
+	ErrorResponse
+	Body struct {
+		Homes []EnergyHome `json:"homes"`
+	} `json:"body"`
+	Status string `json:"status"`
+}
+
+// EnergyHome is home topology, current state of its rooms and modules is requested by HomeStatus.
+type EnergyHome struct {
+	ID      string         `json:"id"`
+	Name    string         `json:"name"`
+	Rooms   []EnergyRoom   `json:"rooms"`
+	Modules []EnergyModule `json:"modules"`
+}
+
+type EnergyRoom struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	ModuleIDs []string `json:"module_ids"`
+}
+
+type EnergyModule struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	RoomID string `json:"room_id"`
+	Bridge string `json:"bridge"`
+}
+
+type HomeStatusResponse struct {
+	ErrorResponse
+	Body struct {
+		Home struct {
+			ID      string         `json:"id"`
+			Rooms   []RoomStatus   `json:"rooms"`
+			Modules []ModuleStatus `json:"modules"`
+		} `json:"home"`
+	} `json:"body"`
+	Status string `json:"status"`
+}
+
+// RoomStatus fields are nil when room has no thermostat or valve reporting them.
+type RoomStatus struct {
+	ID                       string   `json:"id"`
+	Reachable                bool     `json:"reachable"`
+	ThermMeasuredTemperature *float64 `json:"therm_measured_temperature"`
+	ThermSetpointTemperature *float64 `json:"therm_setpoint_temperature"`
+	// ThermSetpointMode is like "schedule", "manual", "away", "hg" (frost guard), "max" or "off".
+	ThermSetpointMode string `json:"therm_setpoint_mode"`
+	// HeatingPowerRequest is percentage of heating requested by the room, valves open accordingly.
+	HeatingPowerRequest *float64 `json:"heating_power_request"`
+	OpenWindow          bool     `json:"open_window"`
+}
+
+// ModuleStatus fields are nil when module type does not report them.
+type ModuleStatus struct {
+	ID           string   `json:"id"`
+	Type         string   `json:"type"`
+	Reachable    *bool    `json:"reachable"`
+	BoilerStatus *bool    `json:"boiler_status"`
+	BatteryLevel *float64 `json:"battery_level"`
+	RfStrength   *float64 `json:"rf_strength"`
+	WifiStrength *float64 `json:"wifi_strength"`
+}
+
+func (c *Client) HomesData() (*HomesDataResponse, error) {
+	var res HomesDataResponse
+	if err := c.Request("/homesdata", nil, &res); err != nil {
+		return nil, fmt.Errorf("requesting /homesdata: %w", err)
+	}
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	return &res, nil
+}
+
+func (c *Client) HomeStatus(homeID string) (*HomeStatusResponse, error) {
+	query := url.Values{}
+	query.Set("home_id", homeID)
+
+	var res HomeStatusResponse
+	if err := c.Request("/homestatus", query, &res); err != nil {
+		return nil, fmt.Errorf("requesting /homestatus: %w", err)
+	}
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	return &res, nil
+}
+
+// PublicDataRequest is bounding box of area to request public stations in.
+type PublicDataRequest struct {
+	LatNE float64
+	LonNE float64
+	LatSW float64
+	LonSW float64
+	// Filter excludes stations with abnormal temperature measurements.
+	Filter bool
+}
+
+type PublicDataResponse struct {
+	ErrorResponse
+	Body   []PublicStation `json:"body"`
+	Status string          `json:"status"`
+}
+
+type PublicStation struct {
+	ID    string `json:"_id"`
+	Place struct {
+		Location []float64 `json:"location"`
+		Altitude float64   `json:"altitude"`
+		City     string    `json:"city"`
+		Country  string    `json:"country"`
+		Timezone string    `json:"timezone"`
+	} `json:"place"`
+	// Measures are keyed by module ID.
+	Measures map[string]PublicMeasure `json:"measures"`
+}
+
+// PublicMeasure is measure of single module.
+// Temperature, humidity and pressure are reported as values in order of Type keyed by Unix timestamp,
+// rain and wind gauges report their own fields.
+type PublicMeasure struct {
+	Res          map[string][]float64 `json:"res"`
+	Type         []string             `json:"type"`
+	RainLive     *float64             `json:"rain_live"`
+	Rain60Min    *float64             `json:"rain_60min"`
+	Rain24H      *float64             `json:"rain_24h"`
+	WindStrength *float64             `json:"wind_strength"`
+	WindAngle    *float64             `json:"wind_angle"`
+	GustStrength *float64             `json:"gust_strength"`
+	GustAngle    *float64             `json:"gust_angle"`
+}
+
+// Latest returns the latest value of given type, like "temperature", or nil if module does not measure it.
+func (pm *PublicMeasure) Latest(measureType string) *float64 {
+	index := -1
+	for i, t := range pm.Type {
+		if t == measureType {
+			index = i
+		}
+	}
+	if index == -1 {
+		return nil
+	}
+
+	var latest int64
+	var value *float64
+	for timestamp, values := range pm.Res {
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil || seconds < latest || index >= len(values) {
+			continue
+		}
+		latest = seconds
+		value = &values[index]
+	}
+	return value
+}
+
+func (c *Client) PublicData(req *PublicDataRequest) (*PublicDataResponse, error) {
+	query := url.Values{}
+	query.Set("lat_ne", strconv.FormatFloat(req.LatNE, 'f', -1, 64))
+	query.Set("lon_ne", strconv.FormatFloat(req.LonNE, 'f', -1, 64))
+	query.Set("lat_sw", strconv.FormatFloat(req.LatSW, 'f', -1, 64))
+	query.Set("lon_sw", strconv.FormatFloat(req.LonSW, 'f', -1, 64))
+	query.Set("filter", strconv.FormatBool(req.Filter))
+
+	var res PublicDataResponse
+	if err := c.Request("/getpublicdata", query, &res); err != nil {
+		return nil, fmt.Errorf("requesting /getpublicdata: %w", err)
+	}
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	return &res, nil
+}
+
+// Request sends authorized GET request and decodes response into dest.
+// When access token is rejected before its expiration and OAuth caches it, token is renewed and request is retried once.
+func (c *Client) Request(endpoint string, query url.Values, dest interface{}) error {
+	return c.request(endpoint, query, dest, true)
+}
+
+func (c *Client) request(endpoint string, query url.Values, dest interface{}, retry bool) error {
+	token, err := c.OAuth.Token(c.Scope)
 	if err != nil {
 		return fmt.Errorf("obtaining OAuth access token: %w", err)
 	}
 
 	url := c.URL + endpoint
+	if len(query) > 0 {
+		url += "?" + query.Encode()
+	}
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
 		return fmt.Errorf("initializing HTTP request: %w", err)
@@ -179,9 +453,27 @@ func (c *Client) Request(endpoint string, dest interface{}) error {
 		return fmt.Errorf("reading response body: %w", err)
 	}
 
+	if invalidator, ok := c.OAuth.(TokenInvalidator); ok && retry && tokenRejected(res.StatusCode, resBody) {
+		invalidator.Invalidate(c.Scope)
+		return c.request(endpoint, query, dest, false)
+	}
+
 	if err := json.Unmarshal(resBody, dest); err != nil {
-		return fmt.Errorf("unmarshaling response body: %w, content: %s", err, resBody)
+		// Content is left out, responses can echo credentials back.
+		return fmt.Errorf("unmarshaling response body of status %d (%d bytes): %w", res.StatusCode, len(resBody), err)
 	}
 
 	return nil
 }
+
+// tokenRejected reports whether response rejects access token that request was authorized with.
+func tokenRejected(statusCode int, resBody []byte) bool {
+	if statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden {
+		return true
+	}
+	var res ErrorResponse
+	if err := json.Unmarshal(resBody, &res); err != nil || res.Error == nil {
+		return false
+	}
+	return res.Error.Code == ErrorCodeInvalidAccessToken || res.Error.Code == ErrorCodeAccessTokenExpired
+}
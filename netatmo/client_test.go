@@ -73,3 +73,68 @@ func TestClient_Error(t *testing.T) {
 	require.Equal(t, response.Error, result.err)
 	require.Nil(t, result.stationsData)
 }
+
+// oauthSequenceMock returns tokens one after another, so renewed token can be told apart.
+type oauthSequenceMock struct {
+	tokens []string
+}
+
+func (oa *oauthSequenceMock) Token(scope string) (*netatmo.OAuthTokenResponse, error) {
+	token := oa.tokens[0]
+	oa.tokens = oa.tokens[1:]
+	return &netatmo.OAuthTokenResponse{AccessToken: token, ExpiresIn: 10800}, nil
+}
+
+func TestClient_RejectedToken(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	oauth := &oauthSequenceMock{tokens: []string{"first-access", "second-access"}}
+	client := netatmo.NewClient(netatmo.NewCachingOAuth(oauth))
+	client.URL = server.URL
+
+	type requestResult struct {
+		stationsData *netatmo.StationsDataResponse
+		err          error
+	}
+	resultChan := make(chan requestResult)
+	go func() {
+		stationsData, err := client.StationsData()
+		resultChan <- requestResult{stationsData, err}
+	}()
+
+	receiveRequest := func() *http.Request {
+		select {
+		case r := <-handler.Requests:
+			return r
+		case <-time.After(time.Second):
+			require.FailNow(t, "request did not arrived")
+			return nil
+		}
+	}
+
+	// Token is rejected long before its expiration, it is renewed and request is retried.
+	r := receiveRequest()
+	require.Equal(t, "Bearer first-access", r.Header.Get("Authorization"))
+	handler.Responses <- netatmo.ErrorResponse{
+		Error: &netatmo.Error{
+			Code:    netatmo.ErrorCodeAccessTokenExpired,
+			Message: "Access token expired",
+		},
+	}
+
+	r = receiveRequest()
+	require.Equal(t, "Bearer second-access", r.Header.Get("Authorization"))
+	handler.Responses <- []byte(`{ "body": { "devices": [] }, "status": "ok" }`)
+
+	var result requestResult
+	select {
+	case result = <-resultChan:
+	case <-time.After(time.Second):
+		require.Fail(t, "result did not arrived")
+	}
+
+	require.NoError(t, result.err)
+	require.Equal(t, "ok", result.stationsData.Status)
+}
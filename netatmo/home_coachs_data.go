@@ -0,0 +1,205 @@
+package netatmo
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+	"github.com/ulexxander/weather-prometheus-exporters/health"
+	"github.com/ulexxander/weather-prometheus-exporters/series"
+)
+
+type homeCoachGauge struct {
+	name      string
+	help      string
+	value     func(device *HomeCoach) float64
+	collector *prometheus.GaugeVec
+}
+
+// HomeCoachsData exports measurements of Healthy Home Coach devices.
+type HomeCoachsData struct {
+	// Health reports outcome of updates, it is registered separately from HomeCoachsData.
+	Health *health.Metrics
+	client *Client
+	config *config.NetatmoHomeCoachsData
+	log    *log.Logger
+	series *series.Tracker
+	gauges []homeCoachGauge
+	// dashboardGauges are measurements, which unreachable device does not have.
+	dashboardGauges []homeCoachGauge
+	firmwareInfo    *prometheus.GaugeVec
+}
+
+func NewHomeCoachsData(client *Client, config *config.NetatmoHomeCoachsData, log *log.Logger) *HomeCoachsData {
+	const namespace = "netatmo"
+	const subsystem = "home_coach"
+	labels := []string{"id", "type", "name"}
+
+	gauges := []homeCoachGauge{
+		{
+			name:  "reachable",
+			value: func(device *HomeCoach) float64 { return boolToFloat(device.Reachable) },
+		},
+		{
+			name:  "wifi_status",
+			value: func(device *HomeCoach) float64 { return float64(device.WifiStatus) },
+		},
+		{
+			name:  "co2_calibrating",
+			value: func(device *HomeCoach) float64 { return boolToFloat(device.Co2Calibrating) },
+		},
+	}
+
+	dashboardGauges := []homeCoachGauge{
+		{
+			name:  "temperature",
+			value: func(device *HomeCoach) float64 { return device.DashboardData.Temperature },
+		},
+		{
+			name:  "humidity",
+			value: func(device *HomeCoach) float64 { return device.DashboardData.Humidity },
+		},
+		{
+			name:  "co2",
+			value: func(device *HomeCoach) float64 { return device.DashboardData.CO2 },
+		},
+		{
+			name:  "noise",
+			value: func(device *HomeCoach) float64 { return device.DashboardData.Noise },
+		},
+		{
+			name:  "pressure",
+			value: func(device *HomeCoach) float64 { return device.DashboardData.Pressure },
+		},
+		{
+			name:  "absolute_pressure",
+			value: func(device *HomeCoach) float64 { return device.DashboardData.AbsolutePressure },
+		},
+		{
+			name:  "health_idx",
+			help:  "Health index from 0 (healthy) to 4 (unhealthy).",
+			value: func(device *HomeCoach) float64 { return device.DashboardData.HealthIdx },
+		},
+		{
+			name:  "measurement_timestamp_seconds",
+			value: func(device *HomeCoach) float64 { return float64(device.DashboardData.TimeUtc) },
+		},
+	}
+
+	for _, gauges := range [][]homeCoachGauge{gauges, dashboardGauges} {
+		for i := range gauges {
+			g := &gauges[i]
+			g.collector = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      g.name,
+				Help:      g.help,
+			}, labels)
+		}
+	}
+
+	firmwareInfo := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "firmware_info",
+		Help:      "Firmware version as label, value is always 1.",
+	}, append(labels[:len(labels):len(labels)], "firmware"))
+
+	return &HomeCoachsData{
+		Health:          health.NewMetrics(namespace, "home_coachs_data"),
+		client:          client,
+		config:          config,
+		log:             log,
+		series:          series.NewTracker(),
+		gauges:          gauges,
+		dashboardGauges: dashboardGauges,
+		firmwareInfo:    firmwareInfo,
+	}
+}
+
+func (hcd *HomeCoachsData) Describe(d chan<- *prometheus.Desc) {
+	for _, g := range hcd.gauges {
+		g.collector.Describe(d)
+	}
+	for _, g := range hcd.dashboardGauges {
+		g.collector.Describe(d)
+	}
+	hcd.firmwareInfo.Describe(d)
+}
+
+func (hcd *HomeCoachsData) Collect(m chan<- prometheus.Metric) {
+	hcd.series.Collect(m)
+}
+
+func (hcd *HomeCoachsData) Run(ctx context.Context) {
+	hcd.Update()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(hcd.config.Interval)):
+			hcd.Update()
+		}
+	}
+}
+
+func (hcd *HomeCoachsData) Update() {
+	start := time.Now()
+
+	homeCoachsData, err := hcd.client.HomeCoachsData()
+	if err != nil {
+		hcd.log.Printf("Error fetching home coachs data: %s", err)
+		hcd.Health.Observe(start, err)
+		return
+	}
+
+	for _, device := range homeCoachsData.Body.Devices {
+		labels := prometheus.Labels{
+			"id":   device.ID,
+			"type": device.Type,
+			"name": homeCoachName(&device),
+		}
+
+		for _, g := range hcd.gauges {
+			hcd.series.Set(g.collector, labels, g.value(&device))
+		}
+		hcd.series.SetInfo(hcd.firmwareInfo, labels, "firmware", strconv.Itoa(device.Firmware), time.Time{})
+
+		// Unreachable device has no dashboard data, its measurements are left to become stale.
+		if !device.Reachable {
+			hcd.log.Printf("Device %s %s (%s) is unreachable", device.Type, labels["name"], device.ID)
+			continue
+		}
+
+		var measuredAt time.Time
+		if hcd.config.SampleTimestamps && device.DashboardData.TimeUtc != 0 {
+			measuredAt = time.Unix(int64(device.DashboardData.TimeUtc), 0)
+		}
+		for _, g := range hcd.dashboardGauges {
+			hcd.series.SetWithTimestamp(g.collector, labels, g.value(&device), measuredAt)
+		}
+
+		hcd.log.Printf("Processed dashboard data of %s device %s (%s)", device.Type, labels["name"], device.ID)
+	}
+
+	if deleted := hcd.series.DeleteStale(start.Add(-time.Duration(hcd.config.StaleGracePeriod))); deleted > 0 {
+		hcd.log.Printf("Deleted %d stale series of home coachs data", deleted)
+	}
+
+	hcd.Health.Observe(start)
+
+	duration := time.Since(start)
+	hcd.log.Println("Updated home coachs data successfully, took", duration)
+}
+
+// homeCoachName returns name of device given by user, older devices have it only as station name.
+func homeCoachName(device *HomeCoach) string {
+	if device.Name != "" {
+		return device.Name
+	}
+	return device.StationName
+}
@@ -0,0 +1,121 @@
+package netatmo_test
+
+import (
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+	"github.com/ulexxander/weather-prometheus-exporters/netatmo"
+	"github.com/ulexxander/weather-prometheus-exporters/testutil"
+)
+
+func TestHomeCoachsData(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := netatmo.NewClient(oauth)
+	client.URL = server.URL
+
+	homeCoachsData := netatmo.NewHomeCoachsData(client, &config.NetatmoHomeCoachsData{}, log.Default())
+
+	reg := prometheus.NewRegistry()
+	err := reg.Register(homeCoachsData)
+	require.NoError(t, err)
+
+	updated := make(chan struct{})
+	go func() {
+		homeCoachsData.Update()
+		updated <- struct{}{}
+	}()
+
+	var r *http.Request
+	select {
+	case r = <-handler.Requests:
+		handler.Responses <- []byte(homeCoachsDataResponse)
+	case <-time.After(time.Second):
+		require.Fail(t, "request did not arrived")
+	}
+
+	<-updated
+
+	require.Equal(t, "/gethomecoachsdata", r.URL.Path)
+
+	gatheredMetrics, err := reg.Gather()
+	require.NoError(t, err)
+
+	// Values by metric name and device name.
+	values := map[string]map[string]float64{}
+	for _, family := range gatheredMetrics {
+		values[family.GetName()] = map[string]float64{}
+		for _, metric := range family.Metric {
+			labels := map[string]string{}
+			for _, label := range metric.Label {
+				labels[label.GetName()] = label.GetValue()
+			}
+			require.Equal(t, netatmo.DeviceTypeHomeCoach, labels["type"], family.GetName())
+			values[family.GetName()][labels["name"]] = metric.GetGauge().GetValue()
+		}
+	}
+
+	require.Equal(t, map[string]float64{"Office": 1, "Meeting room": 0}, values["netatmo_home_coach_reachable"])
+	require.Equal(t, map[string]float64{"Office": 56, "Meeting room": 71}, values["netatmo_home_coach_wifi_status"])
+	require.Equal(t, map[string]float64{"Office": 1, "Meeting room": 1}, values["netatmo_home_coach_firmware_info"])
+	// Unreachable device has no measurements.
+	require.Equal(t, map[string]float64{"Office": 22.4}, values["netatmo_home_coach_temperature"])
+	require.Equal(t, map[string]float64{"Office": 41}, values["netatmo_home_coach_humidity"])
+	require.Equal(t, map[string]float64{"Office": 1043}, values["netatmo_home_coach_co2"])
+	require.Equal(t, map[string]float64{"Office": 48}, values["netatmo_home_coach_noise"])
+	require.Equal(t, map[string]float64{"Office": 1016.3}, values["netatmo_home_coach_pressure"])
+	require.Equal(t, map[string]float64{"Office": 969.7}, values["netatmo_home_coach_absolute_pressure"])
+	require.Equal(t, map[string]float64{"Office": 2}, values["netatmo_home_coach_health_idx"])
+	require.Equal(t, map[string]float64{"Office": 1651487402}, values["netatmo_home_coach_measurement_timestamp_seconds"])
+}
+
+const homeCoachsDataResponse = `{
+  "body": {
+    "devices": [
+      {
+        "_id": "70:ee:50:3f:00:01",
+        "type": "NHC",
+        "name": "Office",
+        "station_name": "Office",
+        "firmware": 45,
+        "wifi_status": 56,
+        "reachable": true,
+        "co2_calibrating": false,
+        "dashboard_data": {
+          "time_utc": 1651487402,
+          "Temperature": 22.4,
+          "CO2": 1043,
+          "Humidity": 41,
+          "Noise": 48,
+          "Pressure": 1016.3,
+          "AbsolutePressure": 969.7,
+          "health_idx": 2,
+          "min_temp": 20.1,
+          "max_temp": 22.9,
+          "date_max_temp": 1651485000,
+          "date_min_temp": 1651460000
+        }
+      },
+      {
+        "_id": "70:ee:50:3f:00:02",
+        "type": "NHC",
+        "station_name": "Meeting room",
+        "firmware": 45,
+        "wifi_status": 71,
+        "reachable": false,
+        "co2_calibrating": false
+      }
+    ]
+  },
+  "status": "ok",
+  "time_exec": 0.031,
+  "time_server": 1651487420
+}`
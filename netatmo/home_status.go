@@ -0,0 +1,250 @@
+package netatmo
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+	"github.com/ulexxander/weather-prometheus-exporters/health"
+	"github.com/ulexxander/weather-prometheus-exporters/series"
+)
+
+// roomGauge is not set when its value returns nil.
+type roomGauge struct {
+	name      string
+	help      string
+	value     func(room *RoomStatus) *float64
+	collector *prometheus.GaugeVec
+}
+
+// energyModuleGauge is not set when its value returns nil.
+type energyModuleGauge struct {
+	name      string
+	help      string
+	value     func(module *ModuleStatus) *float64
+	collector *prometheus.GaugeVec
+}
+
+// HomeStatus exports state of Energy rooms and modules, like thermostats and valves, of all homes.
+type HomeStatus struct {
+	// Health reports outcome of updates, it is registered separately from HomeStatus.
+	Health       *health.Metrics
+	client       *Client
+	config       *config.NetatmoHomeStatus
+	log          *log.Logger
+	series       *series.Tracker
+	roomGauges   []roomGauge
+	setpointMode *prometheus.GaugeVec
+	moduleGauges []energyModuleGauge
+}
+
+func NewHomeStatus(client *Client, config *config.NetatmoHomeStatus, log *log.Logger) *HomeStatus {
+	const namespace = "netatmo"
+	roomLabels := []string{"home_id", "home_name", "room_id", "room_name"}
+	moduleLabels := []string{"home_id", "home_name", "id", "type", "module_name", "room_name"}
+
+	roomGauges := []roomGauge{
+		{
+			name:  "reachable",
+			help:  "Whether modules of the room are reachable.",
+			value: func(room *RoomStatus) *float64 { return fptr(boolToFloat(room.Reachable)) },
+		},
+		{
+			name:  "measured_temperature",
+			help:  "Temperature measured in the room in °C.",
+			value: func(room *RoomStatus) *float64 { return room.ThermMeasuredTemperature },
+		},
+		{
+			name:  "setpoint_temperature",
+			help:  "Temperature the room is heated to in °C.",
+			value: func(room *RoomStatus) *float64 { return room.ThermSetpointTemperature },
+		},
+		// Netatmo API does not report opening of valves, so this is the closest to it.
+		{
+			name:  "heating_power_request",
+			help:  "Percentage of heating requested by the room, valves open accordingly.",
+			value: func(room *RoomStatus) *float64 { return room.HeatingPowerRequest },
+		},
+		{
+			name:  "open_window",
+			help:  "Whether open window was detected in the room, heating is paused then.",
+			value: func(room *RoomStatus) *float64 { return fptr(boolToFloat(room.OpenWindow)) },
+		},
+	}
+	for i := range roomGauges {
+		g := &roomGauges[i]
+		g.collector = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "energy_room",
+			Name:      g.name,
+			Help:      g.help,
+		}, roomLabels)
+	}
+
+	setpointMode := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "energy_room",
+		Name:      "setpoint_mode_info",
+		Help:      "Setpoint mode as label, value is always 1.",
+	}, append(roomLabels[:len(roomLabels):len(roomLabels)], "mode"))
+
+	moduleGauges := []energyModuleGauge{
+		{
+			name:  "reachable",
+			help:  "Whether the module is reachable.",
+			value: func(module *ModuleStatus) *float64 { return optionalBoolToFloat(module.Reachable) },
+		},
+		{
+			name:  "boiler_status",
+			help:  "Whether the relay turns on the boiler.",
+			value: func(module *ModuleStatus) *float64 { return optionalBoolToFloat(module.BoilerStatus) },
+		},
+		{
+			name:  "battery_level",
+			help:  "Battery level of the module in mV.",
+			value: func(module *ModuleStatus) *float64 { return module.BatteryLevel },
+		},
+		{
+			name:  "rf_strength",
+			help:  "Radio signal strength between the module and its relay.",
+			value: func(module *ModuleStatus) *float64 { return module.RfStrength },
+		},
+		{
+			name:  "wifi_strength",
+			help:  "WiFi signal strength of the relay.",
+			value: func(module *ModuleStatus) *float64 { return module.WifiStrength },
+		},
+	}
+	for i := range moduleGauges {
+		g := &moduleGauges[i]
+		g.collector = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "energy_module",
+			Name:      g.name,
+			Help:      g.help,
+		}, moduleLabels)
+	}
+
+	return &HomeStatus{
+		Health:       health.NewMetrics(namespace, "home_status"),
+		client:       client,
+		config:       config,
+		log:          log,
+		series:       series.NewTracker(),
+		roomGauges:   roomGauges,
+		setpointMode: setpointMode,
+		moduleGauges: moduleGauges,
+	}
+}
+
+func (hs *HomeStatus) Describe(d chan<- *prometheus.Desc) {
+	for _, g := range hs.roomGauges {
+		g.collector.Describe(d)
+	}
+	hs.setpointMode.Describe(d)
+	for _, g := range hs.moduleGauges {
+		g.collector.Describe(d)
+	}
+}
+
+func (hs *HomeStatus) Collect(m chan<- prometheus.Metric) {
+	hs.series.Collect(m)
+}
+
+func (hs *HomeStatus) Run(ctx context.Context) {
+	hs.Update()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(hs.config.Interval)):
+			hs.Update()
+		}
+	}
+}
+
+func (hs *HomeStatus) Update() {
+	start := time.Now()
+
+	homesData, err := hs.client.HomesData()
+	if err != nil {
+		hs.log.Printf("Error fetching homes data: %s", err)
+		hs.Health.Observe(start, err)
+		return
+	}
+
+	var errs []error
+	for _, home := range homesData.Body.Homes {
+		status, err := hs.client.HomeStatus(home.ID)
+		if err != nil {
+			hs.log.Printf("Error fetching status of home %s (%s): %s", home.Name, home.ID, err)
+			errs = append(errs, err)
+			continue
+		}
+
+		roomNames := map[string]string{}
+		for _, room := range home.Rooms {
+			roomNames[room.ID] = room.Name
+		}
+		modules := map[string]EnergyModule{}
+		for _, module := range home.Modules {
+			modules[module.ID] = module
+		}
+
+		for _, room := range status.Body.Home.Rooms {
+			labels := prometheus.Labels{
+				"home_id":   home.ID,
+				"home_name": home.Name,
+				"room_id":   room.ID,
+				"room_name": roomNames[room.ID],
+			}
+			for _, g := range hs.roomGauges {
+				if val := g.value(&room); val != nil {
+					hs.series.Set(g.collector, labels, *val)
+				}
+			}
+			if room.ThermSetpointMode != "" {
+				hs.series.SetInfo(hs.setpointMode, labels, "mode", room.ThermSetpointMode, time.Time{})
+			}
+		}
+
+		for _, module := range status.Body.Home.Modules {
+			labels := prometheus.Labels{
+				"home_id":     home.ID,
+				"home_name":   home.Name,
+				"id":          module.ID,
+				"type":        module.Type,
+				"module_name": modules[module.ID].Name,
+				"room_name":   roomNames[modules[module.ID].RoomID],
+			}
+			for _, g := range hs.moduleGauges {
+				if val := g.value(&module); val != nil {
+					hs.series.Set(g.collector, labels, *val)
+				}
+			}
+		}
+
+		hs.log.Printf("Processed status of home %s (%s)", home.Name, home.ID)
+	}
+
+	hs.Health.Finish(health.Update{
+		Name:    "home status",
+		Start:   start,
+		Targets: len(homesData.Body.Homes),
+		Errs:    errs,
+	}, hs.series, time.Duration(hs.config.StaleGracePeriod), hs.log)
+}
+
+func fptr(f float64) *float64 {
+	return &f
+}
+
+func optionalBoolToFloat(b *bool) *float64 {
+	if b == nil {
+		return nil
+	}
+	return fptr(boolToFloat(*b))
+}
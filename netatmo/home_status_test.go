@@ -0,0 +1,144 @@
+package netatmo_test
+
+import (
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+	"github.com/ulexxander/weather-prometheus-exporters/netatmo"
+	"github.com/ulexxander/weather-prometheus-exporters/testutil"
+)
+
+func TestHomeStatus(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := netatmo.NewClient(oauth)
+	client.URL = server.URL
+
+	homeStatus := netatmo.NewHomeStatus(client, &config.NetatmoHomeStatus{}, log.Default())
+
+	reg := prometheus.NewRegistry()
+	err := reg.Register(homeStatus)
+	require.NoError(t, err)
+
+	updated := make(chan struct{})
+	go func() {
+		homeStatus.Update()
+		updated <- struct{}{}
+	}()
+
+	var requests []*http.Request
+	for _, res := range []string{homesDataResponse, homeStatusResponse} {
+		select {
+		case r := <-handler.Requests:
+			requests = append(requests, r)
+			handler.Responses <- []byte(res)
+		case <-time.After(time.Second):
+			require.Fail(t, "request did not arrived")
+		}
+	}
+
+	<-updated
+
+	require.Equal(t, "/homesdata", requests[0].URL.Path)
+	require.Equal(t, "/homestatus", requests[1].URL.Path)
+	require.Equal(t, "5e1a2f1b4b2f1a0001b6c0d1", requests[1].URL.Query().Get("home_id"))
+
+	gatheredMetrics, err := reg.Gather()
+	require.NoError(t, err)
+
+	// Values by metric name and room or module name.
+	values := map[string]map[string]float64{}
+	for _, family := range gatheredMetrics {
+		values[family.GetName()] = map[string]float64{}
+		for _, metric := range family.Metric {
+			labels := map[string]string{}
+			for _, label := range metric.Label {
+				labels[label.GetName()] = label.GetValue()
+			}
+			require.Equal(t, "Apartment", labels["home_name"], family.GetName())
+			key := labels["room_name"]
+			if name, ok := labels["module_name"]; ok {
+				key = name
+			}
+			if mode, ok := labels["mode"]; ok {
+				key += " " + mode
+			}
+			values[family.GetName()][key] = metric.GetGauge().GetValue()
+		}
+	}
+
+	require.Equal(t, map[string]float64{"Living room": 1, "Bedroom": 1}, values["netatmo_energy_room_reachable"])
+	require.Equal(t, map[string]float64{"Living room": 20.5, "Bedroom": 18.2}, values["netatmo_energy_room_measured_temperature"])
+	require.Equal(t, map[string]float64{"Living room": 21, "Bedroom": 17}, values["netatmo_energy_room_setpoint_temperature"])
+	// Room with thermostat does not report heating power request, only rooms with valves do.
+	require.Equal(t, map[string]float64{"Bedroom": 0}, values["netatmo_energy_room_heating_power_request"])
+	require.Equal(t, map[string]float64{"Living room": 0, "Bedroom": 1}, values["netatmo_energy_room_open_window"])
+	require.Equal(t, map[string]float64{"Living room manual": 1, "Bedroom schedule": 1}, values["netatmo_energy_room_setpoint_mode_info"])
+	require.Equal(t, map[string]float64{"Relay": 1, "Thermostat": 1, "Valve": 1}, values["netatmo_energy_module_reachable"])
+	require.Equal(t, map[string]float64{"Thermostat": 1}, values["netatmo_energy_module_boiler_status"])
+	require.Equal(t, map[string]float64{"Thermostat": 4100, "Valve": 2900}, values["netatmo_energy_module_battery_level"])
+	require.Equal(t, map[string]float64{"Thermostat": 60, "Valve": 72}, values["netatmo_energy_module_rf_strength"])
+	require.Equal(t, map[string]float64{"Relay": 48}, values["netatmo_energy_module_wifi_strength"])
+}
+
+const homesDataResponse = `{
+  "body": {
+    "homes": [
+      {
+        "id": "5e1a2f1b4b2f1a0001b6c0d1",
+        "name": "Apartment",
+        "rooms": [
+          { "id": "2255031728", "name": "Living room", "type": "livingroom", "module_ids": ["04:00:00:aa:00:01"] },
+          { "id": "3688132631", "name": "Bedroom", "type": "bedroom", "module_ids": ["09:00:00:aa:00:02"] }
+        ],
+        "modules": [
+          { "id": "70:ee:50:aa:00:00", "type": "NAPlug", "name": "Relay" },
+          { "id": "04:00:00:aa:00:01", "type": "NATherm1", "name": "Thermostat", "room_id": "2255031728" },
+          { "id": "09:00:00:aa:00:02", "type": "NRV", "name": "Valve", "room_id": "3688132631" }
+        ]
+      }
+    ]
+  },
+  "status": "ok"
+}`
+
+const homeStatusResponse = `{
+  "body": {
+    "home": {
+      "id": "5e1a2f1b4b2f1a0001b6c0d1",
+      "rooms": [
+        {
+          "id": "2255031728",
+          "reachable": true,
+          "therm_measured_temperature": 20.5,
+          "therm_setpoint_temperature": 21,
+          "therm_setpoint_mode": "manual",
+          "open_window": false
+        },
+        {
+          "id": "3688132631",
+          "reachable": true,
+          "therm_measured_temperature": 18.2,
+          "therm_setpoint_temperature": 17,
+          "therm_setpoint_mode": "schedule",
+          "heating_power_request": 0,
+          "open_window": true
+        }
+      ],
+      "modules": [
+        { "id": "70:ee:50:aa:00:00", "type": "NAPlug", "reachable": true, "wifi_strength": 48 },
+        { "id": "04:00:00:aa:00:01", "type": "NATherm1", "reachable": true, "boiler_status": true, "battery_level": 4100, "rf_strength": 60 },
+        { "id": "09:00:00:aa:00:02", "type": "NRV", "reachable": true, "battery_level": 2900, "rf_strength": 72 }
+      ]
+    }
+  },
+  "status": "ok"
+}`
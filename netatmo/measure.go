@@ -0,0 +1,120 @@
+package netatmo
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxMeasureLimit is the maximum number of measurements returned by single /getmeasure request.
+const MaxMeasureLimit = 1024
+
+const (
+	ScaleMax       = "max"
+	Scale30Minutes = "30min"
+	Scale1Hour     = "1hour"
+	Scale3Hours    = "3hours"
+	Scale1Day      = "1day"
+	Scale1Week     = "1week"
+	Scale1Month    = "1month"
+)
+
+type MeasureRequest struct {
+	DeviceID string
+	// ModuleID is empty for measurements of the station itself.
+	ModuleID string
+	Scale    string
+	// Types of measurements, like "Temperature" or "CO2".
+	Types     []string
+	DateBegin time.Time
+	DateEnd   time.Time
+	// Limit is number of measurements, MaxMeasureLimit is used when it is 0.
+	Limit int
+}
+
+type MeasureResponse struct {
+	ErrorResponse
+	// Body maps Unix timestamps to values in order of requested types.
+	// Values are nil when there is no measurement of that type.
+	Body   map[string][]*float64 `json:"body"`
+	Status string                `json:"status"`
+}
+
+type Measurement struct {
+	Time time.Time
+	// Values are in order of requested types.
+	Values []*float64
+}
+
+// Measure requests measurements of device or module, ordered by time.
+func (c *Client) Measure(req *MeasureRequest) ([]Measurement, error) {
+	limit := req.Limit
+	if limit == 0 {
+		limit = MaxMeasureLimit
+	}
+
+	query := url.Values{}
+	query.Set("device_id", req.DeviceID)
+	if req.ModuleID != "" {
+		query.Set("module_id", req.ModuleID)
+	}
+	query.Set("scale", req.Scale)
+	query.Set("type", strings.Join(req.Types, ","))
+	query.Set("date_begin", strconv.FormatInt(req.DateBegin.Unix(), 10))
+	query.Set("date_end", strconv.FormatInt(req.DateEnd.Unix(), 10))
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("optimize", "false")
+	query.Set("real_time", "false")
+
+	var res MeasureResponse
+	if err := c.Request("/getmeasure", query, &res); err != nil {
+		return nil, fmt.Errorf("requesting /getmeasure: %w", err)
+	}
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	measurements := make([]Measurement, 0, len(res.Body))
+	for timestamp, values := range res.Body {
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parsing measurement timestamp: %w", err)
+		}
+		measurements = append(measurements, Measurement{
+			Time:   time.Unix(seconds, 0),
+			Values: values,
+		})
+	}
+	sort.Slice(measurements, func(i, j int) bool {
+		return measurements[i].Time.Before(measurements[j].Time)
+	})
+	return measurements, nil
+}
+
+// MeasureAll requests measurements of the whole time range,
+// paging through it when there are more measurements than fit into single response.
+func (c *Client) MeasureAll(req *MeasureRequest) ([]Measurement, error) {
+	page := *req
+	if page.Limit == 0 {
+		page.Limit = MaxMeasureLimit
+	}
+
+	var all []Measurement
+	for {
+		measurements, err := c.Measure(&page)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, measurements...)
+		if len(measurements) < page.Limit {
+			return all, nil
+		}
+		page.DateBegin = measurements[len(measurements)-1].Time.Add(time.Second)
+		if page.DateBegin.After(page.DateEnd) {
+			return all, nil
+		}
+	}
+}
@@ -39,10 +39,28 @@ type OAuthTokenResponse struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+// OAuthError is returned by token endpoint when it rejects the grant, for example "invalid_grant".
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *OAuthError) Error() string {
+	return fmt.Sprintf("netatmo oauth: error=%s description=%s", e.Code, e.Description)
+}
+
+func (e *OAuthError) APIErrorCode() string {
+	return e.Code
+}
+
+type oauthTokenResult struct {
+	OAuthTokenResponse
+	OAuthError
+}
+
 // Token obtains access token using client credentials grant type.
 // Docs: This method can only be used with the same account that the one who owns the API application.
 func (oa *oauth) Token(scope string) (*OAuthTokenResponse, error) {
-	var res OAuthTokenResponse
 	body := url.Values{}
 	body.Set("grant_type", "password")
 	body.Set("client_id", oa.ClientID)
@@ -50,10 +68,53 @@ func (oa *oauth) Token(scope string) (*OAuthTokenResponse, error) {
 	body.Set("username", oa.Username)
 	body.Set("password", oa.Password)
 	body.Set("scope", scope)
+	return oa.requestToken(body)
+}
+
+// Refresh obtains new access token using refresh token grant type.
+// Refresh token returned in the response replaces the one that was used.
+func (oa *oauth) Refresh(refreshToken string) (*OAuthTokenResponse, error) {
+	body := url.Values{}
+	body.Set("grant_type", "refresh_token")
+	body.Set("client_id", oa.ClientID)
+	body.Set("client_secret", oa.ClientSecret)
+	body.Set("refresh_token", refreshToken)
+	return oa.requestToken(body)
+}
+
+// AuthorizeURL returns URL of consent page where user grants access to the application.
+// After that user is redirected to redirectURI with authorization code and given state in query parameters.
+func (oa *oauth) AuthorizeURL(redirectURI, scope, state string) string {
+	query := url.Values{}
+	query.Set("client_id", oa.ClientID)
+	query.Set("redirect_uri", redirectURI)
+	query.Set("scope", scope)
+	query.Set("state", state)
+	return oa.URL + "/authorize?" + query.Encode()
+}
+
+// Exchange obtains access token using authorization code grant type.
+// Code is the one received on redirectURI after user consented on AuthorizeURL.
+func (oa *oauth) Exchange(code, redirectURI, scope string) (*OAuthTokenResponse, error) {
+	body := url.Values{}
+	body.Set("grant_type", "authorization_code")
+	body.Set("client_id", oa.ClientID)
+	body.Set("client_secret", oa.ClientSecret)
+	body.Set("code", code)
+	body.Set("redirect_uri", redirectURI)
+	body.Set("scope", scope)
+	return oa.requestToken(body)
+}
+
+func (oa *oauth) requestToken(body url.Values) (*OAuthTokenResponse, error) {
+	var res oauthTokenResult
 	if err := oa.Request("/token", body, &res); err != nil {
 		return nil, fmt.Errorf("requesting /token: %w", err)
 	}
-	return &res, nil
+	if res.Code != "" {
+		return nil, &res.OAuthError
+	}
+	return &res.OAuthTokenResponse, nil
 }
 
 func (oa *oauth) Request(endpoint string, reqBody url.Values, dest interface{}) error {
@@ -75,7 +136,8 @@ func (oa *oauth) Request(endpoint string, reqBody url.Values, dest interface{})
 	}
 
 	if err := json.Unmarshal(resBody, dest); err != nil {
-		return fmt.Errorf("unmarshaling response body: %w, content: %s", err, resBody)
+		// Content is left out, responses can echo credentials back.
+		return fmt.Errorf("unmarshaling response body of status %d (%d bytes): %w", res.StatusCode, len(resBody), err)
 	}
 
 	return nil
@@ -0,0 +1,102 @@
+package netatmo
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TokenRefresher is implemented by OAuth implementations
+// that can renew access token using refresh token grant type.
+type TokenRefresher interface {
+	Refresh(refreshToken string) (*OAuthTokenResponse, error)
+}
+
+// TokenInvalidator is implemented by OAuth implementations that cache access tokens,
+// so token that API rejected before its expiration is not used again.
+type TokenInvalidator interface {
+	Invalidate(scope string)
+}
+
+// DefaultExpiryMargin is how long before expiration cached access token gets renewed.
+const DefaultExpiryMargin = time.Minute
+
+// CachingOAuth reuses access tokens obtained from underlying OAuth until they are about to expire.
+// Expiring token is renewed using its refresh token if underlying OAuth implements TokenRefresher.
+// Completely new token is requested only when there is no token yet or refreshing it fails.
+type CachingOAuth struct {
+	OAuth        OAuth
+	ExpiryMargin time.Duration
+	Now          func() time.Time
+
+	mu     sync.Mutex
+	tokens map[string]*cachedToken
+}
+
+type cachedToken struct {
+	token     *OAuthTokenResponse
+	expiresAt time.Time
+}
+
+func NewCachingOAuth(oauth OAuth) *CachingOAuth {
+	return &CachingOAuth{
+		OAuth:        oauth,
+		ExpiryMargin: DefaultExpiryMargin,
+		Now:          time.Now,
+		tokens:       map[string]*cachedToken{},
+	}
+}
+
+func (co *CachingOAuth) Token(scope string) (*OAuthTokenResponse, error) {
+	co.mu.Lock()
+	defer co.mu.Unlock()
+
+	now := co.Now()
+	cached, ok := co.tokens[scope]
+	if ok && now.Before(cached.expiresAt.Add(-co.ExpiryMargin)) {
+		return cached.token, nil
+	}
+
+	token, err := co.renew(scope, cached)
+	if err != nil {
+		delete(co.tokens, scope)
+		return nil, err
+	}
+
+	co.tokens[scope] = &cachedToken{
+		token:     token,
+		expiresAt: now.Add(time.Duration(token.ExpiresIn) * time.Second),
+	}
+	return token, nil
+}
+
+// Invalidate marks cached access token of scope as expired, so the next Token renews it.
+// Its refresh token is kept and used for renewal.
+func (co *CachingOAuth) Invalidate(scope string) {
+	co.mu.Lock()
+	defer co.mu.Unlock()
+
+	if cached, ok := co.tokens[scope]; ok {
+		cached.expiresAt = time.Time{}
+	}
+}
+
+func (co *CachingOAuth) renew(scope string, cached *cachedToken) (*OAuthTokenResponse, error) {
+	var refreshErr error
+	if refresher, ok := co.OAuth.(TokenRefresher); ok && cached != nil && cached.token.RefreshToken != "" {
+		token, err := refresher.Refresh(cached.token.RefreshToken)
+		if err == nil {
+			return token, nil
+		}
+		refreshErr = err
+	}
+
+	token, err := co.OAuth.Token(scope)
+	if err != nil {
+		if refreshErr != nil {
+			return nil, fmt.Errorf("obtaining new token after refresh failed (%s): %w", refreshErr, err)
+		}
+		return nil, err
+	}
+	return token, nil
+}
@@ -0,0 +1,185 @@
+package netatmo_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ulexxander/weather-prometheus-exporters/netatmo"
+	"github.com/ulexxander/weather-prometheus-exporters/testutil"
+)
+
+func TestCachingOAuth(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	oauth := netatmo.NewOAuth(
+		"my-clientID",
+		"my-clientSecret",
+		"my-username",
+		"my-password",
+	)
+	oauth.URL = server.URL
+
+	now := time.Date(2022, 5, 2, 12, 0, 0, 0, time.UTC)
+	cachingOAuth := netatmo.NewCachingOAuth(oauth)
+	cachingOAuth.Now = func() time.Time { return now }
+
+	type requestResult struct {
+		token *netatmo.OAuthTokenResponse
+		err   error
+	}
+	requestToken := func() <-chan requestResult {
+		resultChan := make(chan requestResult, 1)
+		go func() {
+			token, err := cachingOAuth.Token("my-scope")
+			resultChan <- requestResult{token, err}
+		}()
+		return resultChan
+	}
+
+	receiveRequest := func() url.Values {
+		var r *http.Request
+		select {
+		case r = <-handler.Requests:
+		case <-time.After(time.Second):
+			require.FailNow(t, "request did not arrived")
+		}
+		require.Equal(t, "/token", r.URL.Path)
+		bodyRaw, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		body, err := url.ParseQuery(string(bodyRaw))
+		require.NoError(t, err)
+		return body
+	}
+
+	receiveResult := func(resultChan <-chan requestResult) requestResult {
+		select {
+		case result := <-resultChan:
+			return result
+		case <-time.After(time.Second):
+			require.FailNow(t, "result did not arrived")
+		}
+		return requestResult{}
+	}
+
+	// No token yet, password grant is used.
+	resultChan := requestToken()
+	body := receiveRequest()
+	require.Equal(t, "password", body.Get("grant_type"))
+	require.Equal(t, "my-scope", body.Get("scope"))
+	firstToken := netatmo.OAuthTokenResponse{
+		AccessToken:  "first-access",
+		ExpiresIn:    3600,
+		RefreshToken: "first-refresh",
+	}
+	handler.Responses <- firstToken
+	result := receiveResult(resultChan)
+	require.NoError(t, result.err)
+	require.Equal(t, firstToken, *result.token)
+
+	// Token is still valid, no request is made.
+	now = now.Add(30 * time.Minute)
+	resultChan = requestToken()
+	select {
+	case <-handler.Requests:
+		require.FailNow(t, "unexpected request while token is cached")
+	case result = <-resultChan:
+	case <-time.After(time.Second):
+		require.FailNow(t, "result did not arrived")
+	}
+	require.NoError(t, result.err)
+	require.Equal(t, firstToken, *result.token)
+
+	// Token is about to expire, refresh token grant is used.
+	now = now.Add(29*time.Minute + 30*time.Second)
+	resultChan = requestToken()
+	body = receiveRequest()
+	expectedBody := url.Values{}
+	expectedBody.Set("grant_type", "refresh_token")
+	expectedBody.Set("client_id", "my-clientID")
+	expectedBody.Set("client_secret", "my-clientSecret")
+	expectedBody.Set("refresh_token", "first-refresh")
+	require.Equal(t, expectedBody, body)
+	secondToken := netatmo.OAuthTokenResponse{
+		AccessToken:  "second-access",
+		ExpiresIn:    3600,
+		RefreshToken: "second-refresh",
+	}
+	handler.Responses <- secondToken
+	result = receiveResult(resultChan)
+	require.NoError(t, result.err)
+	require.Equal(t, secondToken, *result.token)
+
+	// Refresh is rejected, falling back to password grant.
+	now = now.Add(2 * time.Hour)
+	resultChan = requestToken()
+	body = receiveRequest()
+	require.Equal(t, "refresh_token", body.Get("grant_type"))
+	require.Equal(t, "second-refresh", body.Get("refresh_token"))
+	handler.Responses <- netatmo.OAuthError{Code: "invalid_grant"}
+	body = receiveRequest()
+	require.Equal(t, "password", body.Get("grant_type"))
+	thirdToken := netatmo.OAuthTokenResponse{
+		AccessToken:  "third-access",
+		ExpiresIn:    3600,
+		RefreshToken: "third-refresh",
+	}
+	handler.Responses <- thirdToken
+	result = receiveResult(resultChan)
+	require.NoError(t, result.err)
+	require.Equal(t, thirdToken, *result.token)
+}
+
+func TestCachingOAuth_Error(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	oauth := netatmo.NewOAuth(
+		"my-clientID",
+		"my-clientSecret",
+		"my-username",
+		"my-password",
+	)
+	oauth.URL = server.URL
+
+	cachingOAuth := netatmo.NewCachingOAuth(oauth)
+
+	type requestResult struct {
+		token *netatmo.OAuthTokenResponse
+		err   error
+	}
+	resultChan := make(chan requestResult)
+	go func() {
+		token, err := cachingOAuth.Token("my-scope")
+		resultChan <- requestResult{token, err}
+	}()
+
+	select {
+	case <-handler.Requests:
+	case <-time.After(time.Second):
+		require.Fail(t, "request did not arrived")
+	}
+
+	response := netatmo.OAuthError{
+		Code:        "invalid_client",
+		Description: "wrong credentials",
+	}
+	handler.Responses <- response
+
+	var result requestResult
+	select {
+	case result = <-resultChan:
+	case <-time.After(time.Second):
+		require.Fail(t, "result did not arrived")
+	}
+
+	require.Equal(t, &response, result.err)
+	require.Nil(t, result.token)
+}
@@ -80,3 +80,73 @@ func TestOAuth(t *testing.T) {
 	require.NoError(t, result.err)
 	require.Equal(t, response, *result.token)
 }
+
+func TestOAuth_Exchange(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	oauth := netatmo.NewStoredOAuth("my-clientID", "my-clientSecret", nil)
+	oauth.URL = server.URL
+
+	authorizeURL, err := url.Parse(oauth.AuthorizeURL("http://localhost:8080/callback", "my-scope", "my-state"))
+	require.NoError(t, err)
+	require.Equal(t, "/authorize", authorizeURL.Path)
+	expectedQuery := url.Values{}
+	expectedQuery.Set("client_id", "my-clientID")
+	expectedQuery.Set("redirect_uri", "http://localhost:8080/callback")
+	expectedQuery.Set("scope", "my-scope")
+	expectedQuery.Set("state", "my-state")
+	require.Equal(t, expectedQuery, authorizeURL.Query())
+
+	type requestResult struct {
+		token *netatmo.OAuthTokenResponse
+		err   error
+	}
+	resultChan := make(chan requestResult)
+	go func() {
+		token, err := oauth.Exchange("my-code", "http://localhost:8080/callback", "my-scope")
+		resultChan <- requestResult{token, err}
+	}()
+
+	var r *http.Request
+	select {
+	case r = <-handler.Requests:
+	case <-time.After(time.Second):
+		require.Fail(t, "request did not arrived")
+	}
+
+	require.Equal(t, "/token", r.URL.Path)
+
+	bodyRaw, err := io.ReadAll(r.Body)
+	require.NoError(t, err)
+
+	body, err := url.ParseQuery(string(bodyRaw))
+	require.NoError(t, err)
+
+	expectedBody := url.Values{}
+	expectedBody.Set("grant_type", "authorization_code")
+	expectedBody.Set("client_id", "my-clientID")
+	expectedBody.Set("client_secret", "my-clientSecret")
+	expectedBody.Set("code", "my-code")
+	expectedBody.Set("redirect_uri", "http://localhost:8080/callback")
+	expectedBody.Set("scope", "my-scope")
+	require.Equal(t, expectedBody, body)
+
+	response := netatmo.OAuthTokenResponse{
+		AccessToken:  "i2c34r3480rc8n02yu34uhf",
+		ExpiresIn:    10800,
+		RefreshToken: "2423u-9fc-8y2y8-9fy",
+	}
+	handler.Responses <- response
+
+	var result requestResult
+	select {
+	case result = <-resultChan:
+	case <-time.After(time.Second):
+		require.Fail(t, "result did not arrived")
+	}
+
+	require.NoError(t, result.err)
+	require.Equal(t, response, *result.token)
+}
@@ -0,0 +1,224 @@
+package netatmo
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+	"github.com/ulexxander/weather-prometheus-exporters/health"
+	"github.com/ulexxander/weather-prometheus-exporters/series"
+)
+
+// publicDataGauge aggregates measurement of all public stations in area.
+type publicDataGauge struct {
+	name string
+	// value returns measurement of station module, or nil if module does not measure it.
+	value func(measure *PublicMeasure) *float64
+	// angle is direction in degrees, which wraps around, so it is aggregated only as circular mean.
+	angle     bool
+	collector *prometheus.GaugeVec
+}
+
+// PublicData exports measurements of public stations aggregated by configured areas.
+type PublicData struct {
+	// Health reports outcome of updates, it is registered separately from PublicData.
+	Health *health.Metrics
+	client *Client
+	config *config.NetatmoPublicData
+	log    *log.Logger
+	series *series.Tracker
+	gauges []publicDataGauge
+	// stations is number of stations in area that have measurement.
+	stations *prometheus.GaugeVec
+}
+
+func NewPublicData(client *Client, config *config.NetatmoPublicData, log *log.Logger) *PublicData {
+	const namespace = "netatmo"
+	const subsystem = "public_data"
+	labels := []string{"area", "aggregation"}
+
+	latest := func(measureType string) func(measure *PublicMeasure) *float64 {
+		return func(measure *PublicMeasure) *float64 { return measure.Latest(measureType) }
+	}
+	gauges := []publicDataGauge{
+		{name: "temperature", value: latest("temperature")},
+		{name: "humidity", value: latest("humidity")},
+		{name: "pressure", value: latest("pressure")},
+		{name: "rain_live", value: func(m *PublicMeasure) *float64 { return m.RainLive }},
+		{name: "rain_60min", value: func(m *PublicMeasure) *float64 { return m.Rain60Min }},
+		{name: "rain_24h", value: func(m *PublicMeasure) *float64 { return m.Rain24H }},
+		{name: "wind_strength", value: func(m *PublicMeasure) *float64 { return m.WindStrength }},
+		{name: "wind_angle", value: func(m *PublicMeasure) *float64 { return m.WindAngle }, angle: true},
+		{name: "gust_strength", value: func(m *PublicMeasure) *float64 { return m.GustStrength }},
+		{name: "gust_angle", value: func(m *PublicMeasure) *float64 { return m.GustAngle }, angle: true},
+	}
+
+	for i := range gauges {
+		g := &gauges[i]
+		help := "Median, min or max of measurements of public stations in area."
+		if g.angle {
+			help = "Circular mean of directions measured by public stations in area, in degrees."
+		}
+		g.collector = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      g.name,
+			Help:      help,
+		}, labels)
+	}
+
+	stations := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "stations",
+		Help:      "Number of public stations in area that report measurement.",
+	}, []string{"area", "measurement"})
+
+	return &PublicData{
+		Health:   health.NewMetrics(namespace, subsystem),
+		client:   client,
+		config:   config,
+		log:      log,
+		series:   series.NewTracker(),
+		gauges:   gauges,
+		stations: stations,
+	}
+}
+
+func (pd *PublicData) Describe(d chan<- *prometheus.Desc) {
+	for _, g := range pd.gauges {
+		g.collector.Describe(d)
+	}
+	pd.stations.Describe(d)
+}
+
+func (pd *PublicData) Collect(m chan<- prometheus.Metric) {
+	pd.series.Collect(m)
+}
+
+func (pd *PublicData) Run(ctx context.Context) {
+	pd.Update()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(pd.config.Interval)):
+			pd.Update()
+		}
+	}
+}
+
+func (pd *PublicData) Update() {
+	type result struct {
+		area config.NetatmoArea
+		res  *PublicDataResponse
+		err  error
+	}
+
+	results := make(chan result, len(pd.config.Areas))
+	start := time.Now()
+	var errs []error
+
+	for _, area := range pd.config.Areas {
+		go func(area config.NetatmoArea) {
+			res, err := pd.client.PublicData(&PublicDataRequest{
+				LatNE:  area.LatNE,
+				LonNE:  area.LonNE,
+				LatSW:  area.LatSW,
+				LonSW:  area.LonSW,
+				Filter: area.Filter,
+			})
+			results <- result{area, res, err}
+		}(area)
+	}
+
+	for i := 0; i < len(pd.config.Areas); i++ {
+		result := <-results
+		if result.err != nil {
+			pd.log.Printf("Error fetching public data of area %s: %s", result.area.Name, result.err)
+			errs = append(errs, result.err)
+			continue
+		}
+
+		for _, g := range pd.gauges {
+			var values []float64
+			for _, station := range result.res.Body {
+				// Station has at most one module measuring each quantity.
+				for _, measure := range station.Measures {
+					if val := g.value(&measure); val != nil {
+						values = append(values, *val)
+						break
+					}
+				}
+			}
+
+			pd.series.Set(pd.stations, prometheus.Labels{"area": result.area.Name, "measurement": g.name}, float64(len(values)))
+			if len(values) == 0 {
+				continue
+			}
+
+			var aggregations map[string]float64
+			if g.angle {
+				// Directions that cancel each other out have no mean.
+				mean, ok := circularMean(values)
+				if !ok {
+					continue
+				}
+				aggregations = map[string]float64{"mean": mean}
+			} else {
+				sort.Float64s(values)
+				aggregations = map[string]float64{
+					"median": median(values),
+					"min":    values[0],
+					"max":    values[len(values)-1],
+				}
+			}
+			for aggregation, val := range aggregations {
+				pd.series.Set(g.collector, prometheus.Labels{"area": result.area.Name, "aggregation": aggregation}, val)
+			}
+		}
+
+		pd.log.Printf("Processed public data of area %s with %d stations", result.area.Name, len(result.res.Body))
+	}
+
+	pd.Health.Finish(health.Update{
+		Name:    "public data",
+		Start:   start,
+		Targets: len(pd.config.Areas),
+		Errs:    errs,
+	}, pd.series, time.Duration(pd.config.StaleGracePeriod), pd.log)
+}
+
+// median returns median of sorted values.
+func median(sorted []float64) float64 {
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// circularMean returns mean direction of angles in degrees, from 0 to 360.
+// Unlike arithmetic mean it is 0 for 350 and 10 rather than 180.
+// It is not defined when directions cancel each other out, like 0 and 180.
+func circularMean(degrees []float64) (float64, bool) {
+	var sin, cos float64
+	for _, d := range degrees {
+		rad := d * math.Pi / 180
+		sin += math.Sin(rad)
+		cos += math.Cos(rad)
+	}
+	if math.Hypot(sin, cos) < 1e-9*float64(len(degrees)) {
+		return 0, false
+	}
+	mean := math.Atan2(sin, cos) * 180 / math.Pi
+	if mean < 0 {
+		mean += 360
+	}
+	return mean, true
+}
@@ -0,0 +1,169 @@
+package netatmo_test
+
+import (
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+	"github.com/ulexxander/weather-prometheus-exporters/netatmo"
+	"github.com/ulexxander/weather-prometheus-exporters/testutil"
+)
+
+func TestPublicData(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := netatmo.NewClient(oauth)
+	client.URL = server.URL
+
+	config := &config.NetatmoPublicData{
+		Areas: []config.NetatmoArea{
+			{
+				Name:  "Kranj",
+				LatNE: 46.26,
+				LonNE: 14.38,
+				LatSW: 46.22,
+				LonSW: 14.33,
+			},
+		},
+	}
+	publicData := netatmo.NewPublicData(client, config, log.Default())
+
+	reg := prometheus.NewRegistry()
+	err := reg.Register(publicData)
+	require.NoError(t, err)
+
+	updated := make(chan struct{})
+	go func() {
+		publicData.Update()
+		updated <- struct{}{}
+	}()
+
+	var r *http.Request
+	select {
+	case r = <-handler.Requests:
+		handler.Responses <- []byte(publicDataResponse)
+	case <-time.After(time.Second):
+		require.Fail(t, "request did not arrived")
+	}
+
+	<-updated
+
+	require.Equal(t, "/getpublicdata", r.URL.Path)
+	query := r.URL.Query()
+	require.Equal(t, "46.26", query.Get("lat_ne"))
+	require.Equal(t, "14.38", query.Get("lon_ne"))
+	require.Equal(t, "46.22", query.Get("lat_sw"))
+	require.Equal(t, "14.33", query.Get("lon_sw"))
+	require.Equal(t, "false", query.Get("filter"))
+
+	gatheredMetrics, err := reg.Gather()
+	require.NoError(t, err)
+
+	// Values by metric name and aggregation or measurement label.
+	values := map[string]map[string]float64{}
+	for _, family := range gatheredMetrics {
+		values[family.GetName()] = map[string]float64{}
+		for _, metric := range family.Metric {
+			labels := map[string]string{}
+			for _, label := range metric.Label {
+				labels[label.GetName()] = label.GetValue()
+			}
+			require.Equal(t, "Kranj", labels["area"], family.GetName())
+			key := labels["aggregation"] + labels["measurement"]
+			values[family.GetName()][key] = metric.GetGauge().GetValue()
+		}
+	}
+
+	require.Equal(t, map[string]float64{"median": 16.9, "min": 16.2, "max": 17.4}, values["netatmo_public_data_temperature"])
+	require.Equal(t, map[string]float64{"median": 58, "min": 55, "max": 61}, values["netatmo_public_data_humidity"])
+	require.Equal(t, map[string]float64{"median": 1012.15, "min": 1011.9, "max": 1012.4}, values["netatmo_public_data_pressure"])
+	require.Equal(t, map[string]float64{"median": 0.3, "min": 0.3, "max": 0.3}, values["netatmo_public_data_rain_60min"])
+	require.Equal(t, map[string]float64{"median": 5, "min": 4, "max": 6}, values["netatmo_public_data_wind_strength"])
+	// Angles wrap around, mean of 290 and 10 is 330 rather than 150.
+	require.InDelta(t, 330, values["netatmo_public_data_wind_angle"]["mean"], 1e-9)
+	require.InDelta(t, 320, values["netatmo_public_data_gust_angle"]["mean"], 1e-9)
+	require.Len(t, values["netatmo_public_data_wind_angle"], 1)
+	require.Equal(t, map[string]float64{
+		"temperature":   3,
+		"humidity":      3,
+		"pressure":      2,
+		"rain_live":     1,
+		"rain_60min":    1,
+		"rain_24h":      1,
+		"wind_strength": 2,
+		"wind_angle":    2,
+		"gust_strength": 2,
+		"gust_angle":    2,
+	}, values["netatmo_public_data_stations"])
+}
+
+const publicDataResponse = `{
+  "body": [
+    {
+      "_id": "70:ee:50:00:00:01",
+      "place": { "location": [14.35, 46.24], "altitude": 385, "city": "Kranj", "country": "SI", "timezone": "Europe/Ljubljana" },
+      "measures": {
+        "02:00:00:00:00:01": {
+          "res": { "1651487000": [16.5, 60], "1651487300": [16.9, 58] },
+          "type": ["temperature", "humidity"]
+        },
+        "70:ee:50:00:00:01": {
+          "res": { "1651487300": [1012.4] },
+          "type": ["pressure"]
+        },
+        "05:00:00:00:00:01": {
+          "rain_60min": 0.3,
+          "rain_24h": 2.1,
+          "rain_live": 0,
+          "rain_timeutc": 1651487310
+        },
+        "06:00:00:00:00:01": {
+          "wind_strength": 4,
+          "wind_angle": 290,
+          "gust_strength": 9,
+          "gust_angle": 285,
+          "wind_timeutc": 1651487310
+        }
+      }
+    },
+    {
+      "_id": "70:ee:50:00:00:02",
+      "place": { "location": [14.36, 46.23], "altitude": 390, "city": "Kranj", "country": "SI", "timezone": "Europe/Ljubljana" },
+      "measures": {
+        "02:00:00:00:00:02": {
+          "res": { "1651487200": [17.4, 55] },
+          "type": ["temperature", "humidity"]
+        },
+        "70:ee:50:00:00:02": {
+          "res": { "1651487200": [1011.9] },
+          "type": ["pressure"]
+        },
+        "06:00:00:00:00:02": {
+          "wind_strength": 6,
+          "wind_angle": 10,
+          "gust_strength": 11,
+          "gust_angle": 355,
+          "wind_timeutc": 1651487290
+        }
+      }
+    },
+    {
+      "_id": "70:ee:50:00:00:03",
+      "place": { "location": [14.34, 46.25], "altitude": 410, "city": "Kranj", "country": "SI", "timezone": "Europe/Ljubljana" },
+      "measures": {
+        "02:00:00:00:00:03": {
+          "res": { "1651487100": [16.2, 61] },
+          "type": ["temperature", "humidity"]
+        }
+      }
+    }
+  ],
+  "status": "ok"
+}`
@@ -0,0 +1,246 @@
+package netatmo
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+	"github.com/ulexxander/weather-prometheus-exporters/source"
+)
+
+func init() {
+	source.Register(NewSource())
+}
+
+// Source runs Netatmo collectors enabled in config for every account.
+type Source struct {
+	// clients are reused between reloads,
+	// so refresh token of account is not rotated by old and new client at the same time.
+	clients map[clientKey]*Client
+}
+
+// clientKey has resolved credentials, so client is replaced when secret file changes.
+type clientKey struct {
+	account     config.NetatmoAccount
+	credentials Credentials
+	scope       string
+}
+
+func NewSource() *Source {
+	return &Source{
+		clients: map[clientKey]*Client{},
+	}
+}
+
+func (s *Source) Name() string {
+	return "Netatmo"
+}
+
+func (s *Source) Enabled(cfg *config.Config) bool {
+	n := &cfg.Netatmo
+	return n.StationsData.Enabled || n.PublicData.Enabled || n.HomeCoachsData.Enabled || n.HomeStatus.Enabled
+}
+
+func (s *Source) Validate(cfg *config.Config) error {
+	var v config.Validator
+	n := &cfg.Netatmo
+	if len(n.Accounts) > 0 && n.TokenFile != "" {
+		v.Addf("Netatmo.TokenFile must not be set with Netatmo.Accounts, set TokenFile of accounts instead")
+	}
+	if len(n.Accounts) > 1 {
+		names := map[string]bool{}
+		for i, account := range n.Accounts {
+			if account.Name == "" {
+				v.Addf("Netatmo.Accounts[%d].Name must not be empty when multiple accounts are configured", i)
+			} else if names[account.Name] {
+				v.Addf("Netatmo.Accounts[%d].Name %q is a duplicate", i, account.Name)
+			}
+			names[account.Name] = true
+		}
+	}
+
+	if n.StationsData.Enabled {
+		v.Interval("Netatmo.StationsData", n.StationsData.Interval)
+	}
+	if n.HomeCoachsData.Enabled {
+		v.Interval("Netatmo.HomeCoachsData", n.HomeCoachsData.Interval)
+	}
+	if n.HomeStatus.Enabled {
+		v.Interval("Netatmo.HomeStatus", n.HomeStatus.Interval)
+	}
+	if n.PublicData.Enabled {
+		v.Interval("Netatmo.PublicData", n.PublicData.Interval)
+		validateAreas(&v, n.PublicData.Areas)
+	}
+	return v.Err()
+}
+
+func validateAreas(v *config.Validator, areas []config.NetatmoArea) {
+	if len(areas) == 0 {
+		v.Addf("Netatmo.PublicData.Areas must not be empty")
+	}
+	names := map[string]bool{}
+	for i, area := range areas {
+		path := fmt.Sprintf("Netatmo.PublicData.Areas[%d]", i)
+		if area.Name == "" {
+			v.Addf("%s.Name must not be empty", path)
+		} else if names[area.Name] {
+			v.Addf("%s.Name %q is a duplicate", path, area.Name)
+		}
+		names[area.Name] = true
+		if area.LatNE < -90 || area.LatNE > 90 || area.LatSW < -90 || area.LatSW > 90 {
+			v.Addf("%s latitudes must be between -90 and 90", path)
+		}
+		if area.LonNE < -180 || area.LonNE > 180 || area.LonSW < -180 || area.LonSW > 180 {
+			v.Addf("%s longitudes must be between -180 and 180", path)
+		}
+		if area.LatNE <= area.LatSW {
+			v.Addf("%s.LatNE must be north of LatSW", path)
+		}
+	}
+}
+
+func (s *Source) CheckCredentials(cfg *config.Config) error {
+	var errs []string
+	for _, account := range cfg.Netatmo.AllAccounts() {
+		if _, err := ResolveCredentials(&account); err != nil {
+			errs = append(errs, accountError(&account, err).Error())
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(errs, "; "))
+}
+
+func (s *Source) SecretFiles(cfg *config.Config) []string {
+	var files []string
+	for _, account := range cfg.Netatmo.AllAccounts() {
+		prefix := envPrefix(&account)
+		files = source.AppendSecretFile(files, account.ClientID, prefix+"CLIENT_ID")
+		files = source.AppendSecretFile(files, account.ClientSecret, prefix+"CLIENT_SECRET")
+		if account.TokenFile == "" {
+			files = source.AppendSecretFile(files, account.Username, prefix+"USERNAME")
+			files = source.AppendSecretFile(files, account.Password, prefix+"PASSWORD")
+		}
+	}
+	return files
+}
+
+// Jobs runs collectors of user devices for every account, Public Data runs once.
+func (s *Source) Jobs(cfg *config.Config, log *log.Logger) ([]*source.Job, error) {
+	config := &cfg.Netatmo
+	if !config.StationsData.Enabled {
+		log.Print("Netatmo Stations Data is disabled")
+	}
+	if !config.PublicData.Enabled {
+		log.Print("Netatmo Public Data is disabled")
+	}
+	if !config.HomeCoachsData.Enabled {
+		log.Print("Netatmo Home Coachs Data is disabled")
+	}
+	if !config.HomeStatus.Enabled {
+		log.Print("Netatmo Home Status is disabled")
+	}
+	if !s.Enabled(cfg) {
+		return nil, nil
+	}
+
+	scope := Scope(config)
+	clients := map[clientKey]*Client{}
+	var jobs []*source.Job
+	var firstClient *Client
+	var firstKey clientKey
+	for _, account := range config.AllAccounts() {
+		creds, err := ResolveCredentials(&account)
+		if err != nil {
+			return nil, accountError(&account, err)
+		}
+		key := clientKey{account, *creds, scope}
+		client, ok := s.clients[key]
+		if !ok {
+			client = NewAccountClient(&account, creds, scope)
+		}
+		clients[key] = client
+		if firstClient == nil {
+			firstClient = client
+			firstKey = key
+		}
+
+		jobs = append(jobs, accountJobs(config, client, &key, log)...)
+	}
+
+	// Public stations are the same for every account, so they are exported once without account label.
+	if config.PublicData.Enabled {
+		publicData := NewPublicData(firstClient, &config.PublicData, log)
+		jobs = append(jobs, &source.Job{
+			Name:       "Netatmo Public Data",
+			Config:     []interface{}{firstKey, config.PublicData},
+			Registerer: prometheus.DefaultRegisterer,
+			Collector:  publicData,
+			Health:     publicData.Health,
+		})
+	}
+
+	s.clients = clients
+	return jobs, nil
+}
+
+// accountJobs returns jobs of collectors of user devices, their series are labeled with account name if it has one.
+func accountJobs(config *config.Netatmo, client *Client, key *clientKey, log *log.Logger) []*source.Job {
+	account := &key.account
+	reg := prometheus.DefaultRegisterer
+	suffix := ""
+	if account.Name != "" {
+		reg = prometheus.WrapRegistererWith(prometheus.Labels{"account": account.Name}, reg)
+		log = accountLogger(log, account)
+		suffix = fmt.Sprintf(" of account %s", account.Name)
+	}
+
+	var jobs []*source.Job
+
+	if config.StationsData.Enabled {
+		stationsData := NewStationsData(client, &config.StationsData, log)
+		jobs = append(jobs, &source.Job{
+			Name:       "Netatmo Stations Data" + suffix,
+			Config:     []interface{}{*key, config.StationsData},
+			Registerer: reg,
+			Collector:  stationsData,
+			Health:     stationsData.Health,
+		})
+	}
+
+	if config.HomeCoachsData.Enabled {
+		homeCoachsData := NewHomeCoachsData(client, &config.HomeCoachsData, log)
+		jobs = append(jobs, &source.Job{
+			Name:       "Netatmo Home Coachs Data" + suffix,
+			Config:     []interface{}{*key, config.HomeCoachsData},
+			Registerer: reg,
+			Collector:  homeCoachsData,
+			Health:     homeCoachsData.Health,
+		})
+	}
+
+	if config.HomeStatus.Enabled {
+		homeStatus := NewHomeStatus(client, &config.HomeStatus, log)
+		jobs = append(jobs, &source.Job{
+			Name:       "Netatmo Home Status" + suffix,
+			Config:     []interface{}{*key, config.HomeStatus},
+			Registerer: reg,
+			Collector:  homeStatus,
+			Health:     homeStatus.Health,
+		})
+	}
+
+	return jobs
+}
+
+func accountError(account *config.NetatmoAccount, err error) error {
+	if account.Name == "" {
+		return err
+	}
+	return fmt.Errorf("account %s: %w", account.Name, err)
+}
@@ -0,0 +1,92 @@
+package netatmo_test
+
+import (
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+	"github.com/ulexxander/weather-prometheus-exporters/netatmo"
+)
+
+func TestSource_Jobs(t *testing.T) {
+	secret := func(value string) config.Secret {
+		return config.Secret{Value: value}
+	}
+	cfg := &config.Config{
+		Netatmo: config.Netatmo{
+			Accounts: []config.NetatmoAccount{
+				{Name: "home", ClientID: secret("id"), ClientSecret: secret("secret"), Username: secret("user"), Password: secret("pass")},
+				{Name: "office", ClientID: secret("id"), ClientSecret: secret("secret"), Username: secret("user2"), Password: secret("pass")},
+			},
+			StationsData: config.NetatmoStationsData{Enabled: true, Interval: config.Duration(time.Minute)},
+			PublicData:   config.NetatmoPublicData{Enabled: true, Interval: config.Duration(time.Minute)},
+		},
+	}
+	src := netatmo.NewSource()
+	logger := log.New(io.Discard, "", 0)
+
+	require.True(t, src.Enabled(cfg))
+	require.NoError(t, src.CheckCredentials(cfg))
+
+	jobs, err := src.Jobs(cfg, logger)
+	require.NoError(t, err)
+	var names []string
+	for _, j := range jobs {
+		names = append(names, j.Name)
+	}
+	require.Equal(t, []string{
+		"Netatmo Stations Data of account home",
+		"Netatmo Stations Data of account office",
+		"Netatmo Public Data",
+	}, names)
+
+	// Changed credentials of one account change config of its jobs only.
+	cfg.Netatmo.Accounts[1].Password = secret("changed")
+	reloaded, err := src.Jobs(cfg, logger)
+	require.NoError(t, err)
+	require.Equal(t, jobs[0].Config, reloaded[0].Config)
+	require.NotEqual(t, jobs[1].Config, reloaded[1].Config)
+
+	cfg.Netatmo.Accounts[0].Password = config.Secret{Env: "NETATMO_TEST_UNSET_PASSWORD"}
+	require.EqualError(t, src.CheckCredentials(cfg), "account home: missing credentials: Password: environment variable NETATMO_TEST_UNSET_PASSWORD or NETATMO_TEST_UNSET_PASSWORD_FILE is not set")
+}
+
+func TestSource_Validate(t *testing.T) {
+	cfg := &config.Config{
+		Netatmo: config.Netatmo{
+			Accounts: []config.NetatmoAccount{{Name: "home"}, {Name: "home"}},
+			StationsData: config.NetatmoStationsData{
+				Enabled: true,
+			},
+			PublicData: config.NetatmoPublicData{
+				Enabled:  true,
+				Interval: config.Duration(time.Minute),
+				Areas:    []config.NetatmoArea{{Name: "kranj", LatNE: 46.22, LonNE: 14.38, LatSW: 46.26, LonSW: 14.33}},
+			},
+		},
+	}
+
+	err := netatmo.NewSource().Validate(cfg)
+	var validationErr *config.ValidationError
+	require.True(t, errors.As(err, &validationErr), err)
+	require.Equal(t, []string{
+		`Netatmo.Accounts[1].Name "home" is a duplicate`,
+		"Netatmo.StationsData.Interval must be at least 1s, got 0s",
+		"Netatmo.PublicData.Areas[0].LatNE must be north of LatSW",
+	}, validationErr.Problems)
+}
+
+func TestSource_ValidateDisabledCollectors(t *testing.T) {
+	cfg := &config.Config{
+		Netatmo: config.Netatmo{
+			PublicData: config.NetatmoPublicData{
+				Areas: []config.NetatmoArea{{LatNE: 95}},
+			},
+		},
+	}
+	require.NoError(t, netatmo.NewSource().Validate(cfg))
+}
@@ -3,19 +3,50 @@ package netatmo
 import (
 	"context"
 	"log"
+	"strconv"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/ulexxander/weather-prometheus-exporters/config"
+	"github.com/ulexxander/weather-prometheus-exporters/health"
+	"github.com/ulexxander/weather-prometheus-exporters/series"
 )
 
 type StationsData struct {
-	client              *Client
-	config              *config.NetatmoStationsData
-	log                 *log.Logger
-	indoorModuleGauges  []indoorModuleGauge
-	outdoorModuleGauges []outdoorModuleGauge
-	windModuleGauges    []windModuleGauge
+	// Health reports outcome of updates, it is registered separately from StationsData.
+	Health                  *health.Metrics
+	client                  *Client
+	config                  *config.NetatmoStationsData
+	log                     *log.Logger
+	series                  *series.Tracker
+	stationGauges           []stationGauge
+	stationFirmwareInfo     *prometheus.GaugeVec
+	stationDashboardGauges  []stationGauge
+	moduleGauges            []moduleGauge
+	moduleFirmwareInfo      *prometheus.GaugeVec
+	moduleDashboardGauges   []moduleGauge
+	indoorModuleGauges      []indoorModuleGauge
+	outdoorModuleGauges     []outdoorModuleGauge
+	windModuleGauges        []windModuleGauge
+	rainModuleGauges        []rainModuleGauge
+	indoorExtraModuleGauges []indoorExtraModuleGauge
+	indoorModuleTrends      []indoorModuleTrend
+	outdoorModuleTrends     []outdoorModuleTrend
+	indoorExtraModuleTrends []outdoorModuleTrend
+}
+
+// stationGauge is exported for every station regardless of its type.
+type stationGauge struct {
+	name      string
+	value     func(device *Device) float64
+	collector *prometheus.GaugeVec
+}
+
+// moduleGauge is exported for every module regardless of its type.
+type moduleGauge struct {
+	name      string
+	value     func(module *Module) float64
+	collector *prometheus.GaugeVec
 }
 
 type indoorModuleGauge struct {
@@ -36,11 +67,98 @@ type windModuleGauge struct {
 	collector *prometheus.GaugeVec
 }
 
+type rainModuleGauge struct {
+	name      string
+	value     func(data *RainModuleData) float64
+	collector *prometheus.GaugeVec
+}
+
+type indoorExtraModuleGauge struct {
+	name      string
+	value     func(data *IndoorExtraModuleData) float64
+	collector *prometheus.GaugeVec
+}
+
+// indoorModuleTrend is exported as number: -1 when trend is down, 0 when stable and 1 when up.
+// It is also exported as trend label of info metric.
+type indoorModuleTrend struct {
+	name      string
+	value     func(data *IndoorModuleData) string
+	collector *prometheus.GaugeVec
+	info      *prometheus.GaugeVec
+}
+
+// outdoorModuleTrend is the same as indoorModuleTrend.
+// It is used for additional indoor modules too, which have the same trend fields as outdoor module.
+type outdoorModuleTrend struct {
+	name      string
+	value     func(data *OutdoorModuleData) string
+	collector *prometheus.GaugeVec
+	info      *prometheus.GaugeVec
+}
+
 func NewStationsData(client *Client, config *config.NetatmoStationsData, log *log.Logger) *StationsData {
 	const namespace = "netatmo"
 	stationLabels := []string{"home_id", "home_name", "id", "type", "station_name"}
 	moduleLabels := []string{"home_id", "home_name", "id", "type", "module_name"}
 
+	stationGauges := []stationGauge{
+		{
+			name:  "reachable",
+			value: func(device *Device) float64 { return boolToFloat(device.Reachable) },
+		},
+		{
+			name:  "wifi_status",
+			value: func(device *Device) float64 { return float64(device.WifiStatus) },
+		},
+		{
+			name:  "co2_calibrating",
+			value: func(device *Device) float64 { return boolToFloat(device.Co2Calibrating) },
+		},
+	}
+
+	stationDashboardGauges := []stationGauge{
+		{
+			name:  "measurement_timestamp_seconds",
+			value: func(device *Device) float64 { return float64(device.DashboardData.TimeUtc) },
+		},
+	}
+
+	moduleGauges := []moduleGauge{
+		{
+			name:  "reachable",
+			value: func(module *Module) float64 { return boolToFloat(module.Reachable) },
+		},
+		{
+			name:  "battery_percent",
+			value: func(module *Module) float64 { return float64(module.BatteryPercent) },
+		},
+		{
+			// Battery voltage is reported in millivolts.
+			name:  "battery_voltage",
+			value: func(module *Module) float64 { return float64(module.BatteryVp) / 1000 },
+		},
+		{
+			name:  "rf_status",
+			value: func(module *Module) float64 { return float64(module.RfStatus) },
+		},
+		{
+			name:  "last_seen_timestamp_seconds",
+			value: func(module *Module) float64 { return float64(module.LastSeen) },
+		},
+		{
+			name:  "last_message_timestamp_seconds",
+			value: func(module *Module) float64 { return float64(module.LastMessage) },
+		},
+	}
+
+	moduleDashboardGauges := []moduleGauge{
+		{
+			name:  "measurement_timestamp_seconds",
+			value: func(module *Module) float64 { return float64(module.DashboardData.TimeUtc) },
+		},
+	}
+
 	indoorModuleGauges := []indoorModuleGauge{
 		{
 			name:  "absolute_pressure",
@@ -66,6 +184,23 @@ func NewStationsData(client *Client, config *config.NetatmoStationsData, log *lo
 			name:  "temperature",
 			value: func(data *IndoorModuleData) float64 { return data.Temperature },
 		},
+		// Daily extremes.
+		{
+			name:  "min_temp",
+			value: func(data *IndoorModuleData) float64 { return data.MinTemp },
+		},
+		{
+			name:  "min_temp_timestamp_seconds",
+			value: func(data *IndoorModuleData) float64 { return float64(data.DateMinTemp) },
+		},
+		{
+			name:  "max_temp",
+			value: func(data *IndoorModuleData) float64 { return data.MaxTemp },
+		},
+		{
+			name:  "max_temp_timestamp_seconds",
+			value: func(data *IndoorModuleData) float64 { return float64(data.DateMaxTemp) },
+		},
 	}
 
 	outdoorModuleGauges := []outdoorModuleGauge{
@@ -77,6 +212,23 @@ func NewStationsData(client *Client, config *config.NetatmoStationsData, log *lo
 			name:  "temperature",
 			value: func(data *OutdoorModuleData) float64 { return data.Temperature },
 		},
+		// Daily extremes.
+		{
+			name:  "min_temp",
+			value: func(data *OutdoorModuleData) float64 { return data.MinTemp },
+		},
+		{
+			name:  "min_temp_timestamp_seconds",
+			value: func(data *OutdoorModuleData) float64 { return float64(data.DateMinTemp) },
+		},
+		{
+			name:  "max_temp",
+			value: func(data *OutdoorModuleData) float64 { return data.MaxTemp },
+		},
+		{
+			name:  "max_temp_timestamp_seconds",
+			value: func(data *OutdoorModuleData) float64 { return float64(data.DateMaxTemp) },
+		},
 	}
 
 	windModuleGauges := []windModuleGauge{
@@ -96,6 +248,127 @@ func NewStationsData(client *Client, config *config.NetatmoStationsData, log *lo
 			name:  "wind_strength",
 			value: func(data *WindModuleData) float64 { return data.WindStrength },
 		},
+		// Daily extremes.
+		{
+			name:  "max_wind_str",
+			value: func(data *WindModuleData) float64 { return data.MaxWindStr },
+		},
+		{
+			name:  "max_wind_angle",
+			value: func(data *WindModuleData) float64 { return data.MaxWindAngle },
+		},
+		{
+			name:  "max_wind_str_timestamp_seconds",
+			value: func(data *WindModuleData) float64 { return float64(data.DateMaxWindStr) },
+		},
+	}
+
+	rainModuleGauges := []rainModuleGauge{
+		{
+			name:  "rain",
+			value: func(data *RainModuleData) float64 { return data.Rain },
+		},
+		{
+			name:  "sum_rain_1",
+			value: func(data *RainModuleData) float64 { return data.SumRain1 },
+		},
+		{
+			name:  "sum_rain_24",
+			value: func(data *RainModuleData) float64 { return data.SumRain24 },
+		},
+	}
+
+	indoorExtraModuleGauges := []indoorExtraModuleGauge{
+		{
+			name:  "co2",
+			value: func(data *IndoorExtraModuleData) float64 { return data.CO2 },
+		},
+		{
+			name:  "humidity",
+			value: func(data *IndoorExtraModuleData) float64 { return data.Humidity },
+		},
+		{
+			name:  "temperature",
+			value: func(data *IndoorExtraModuleData) float64 { return data.Temperature },
+		},
+		// Daily extremes.
+		{
+			name:  "min_temp",
+			value: func(data *IndoorExtraModuleData) float64 { return data.MinTemp },
+		},
+		{
+			name:  "min_temp_timestamp_seconds",
+			value: func(data *IndoorExtraModuleData) float64 { return float64(data.DateMinTemp) },
+		},
+		{
+			name:  "max_temp",
+			value: func(data *IndoorExtraModuleData) float64 { return data.MaxTemp },
+		},
+		{
+			name:  "max_temp_timestamp_seconds",
+			value: func(data *IndoorExtraModuleData) float64 { return float64(data.DateMaxTemp) },
+		},
+	}
+
+	indoorModuleTrends := []indoorModuleTrend{
+		{
+			name:  "pressure_trend",
+			value: func(data *IndoorModuleData) string { return data.PressureTrend },
+		},
+		{
+			name:  "temp_trend",
+			value: func(data *IndoorModuleData) string { return data.TempTrend },
+		},
+	}
+
+	outdoorModuleTrends := []outdoorModuleTrend{
+		{
+			name:  "temp_trend",
+			value: func(data *OutdoorModuleData) string { return data.TempTrend },
+		},
+	}
+
+	indoorExtraModuleTrends := []outdoorModuleTrend{
+		{
+			name:  "temp_trend",
+			value: func(data *OutdoorModuleData) string { return data.TempTrend },
+		},
+	}
+
+	stationFirmwareInfo := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "station",
+		Name:      "firmware_info",
+		Help:      "Firmware version of the station, value is always 1.",
+	}, append(stationLabels, "firmware"))
+
+	moduleFirmwareInfo := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "module",
+		Name:      "firmware_info",
+		Help:      "Firmware version of the module, value is always 1.",
+	}, append(moduleLabels, "firmware"))
+
+	for _, gauges := range [][]stationGauge{stationGauges, stationDashboardGauges} {
+		for i := range gauges {
+			g := &gauges[i]
+			g.collector = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "station",
+				Name:      g.name,
+			}, stationLabels)
+		}
+	}
+
+	for _, gauges := range [][]moduleGauge{moduleGauges, moduleDashboardGauges} {
+		for i := range gauges {
+			g := &gauges[i]
+			g.collector = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: "module",
+				Name:      g.name,
+			}, moduleLabels)
+		}
 	}
 
 	for i := range indoorModuleGauges {
@@ -125,17 +398,92 @@ func NewStationsData(client *Client, config *config.NetatmoStationsData, log *lo
 		}, moduleLabels)
 	}
 
+	for i := range rainModuleGauges {
+		g := &rainModuleGauges[i]
+		g.collector = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "rain_module",
+			Name:      g.name,
+		}, moduleLabels)
+	}
+
+	for i := range indoorExtraModuleGauges {
+		g := &indoorExtraModuleGauges[i]
+		g.collector = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "indoor_extra_module",
+			Name:      g.name,
+		}, moduleLabels)
+	}
+
+	for i := range indoorModuleTrends {
+		t := &indoorModuleTrends[i]
+		t.collector, t.info = newTrendCollectors(namespace, "indoor_module", t.name, stationLabels)
+	}
+
+	for i := range outdoorModuleTrends {
+		t := &outdoorModuleTrends[i]
+		t.collector, t.info = newTrendCollectors(namespace, "outdoor_module", t.name, moduleLabels)
+	}
+
+	for i := range indoorExtraModuleTrends {
+		t := &indoorExtraModuleTrends[i]
+		t.collector, t.info = newTrendCollectors(namespace, "indoor_extra_module", t.name, moduleLabels)
+	}
+
 	return &StationsData{
-		client:              client,
-		config:              config,
-		log:                 log,
-		indoorModuleGauges:  indoorModuleGauges,
-		outdoorModuleGauges: outdoorModuleGauges,
-		windModuleGauges:    windModuleGauges,
+		Health:                  health.NewMetrics(namespace, "stations_data"),
+		client:                  client,
+		config:                  config,
+		log:                     log,
+		series:                  series.NewTracker(),
+		stationGauges:           stationGauges,
+		stationFirmwareInfo:     stationFirmwareInfo,
+		stationDashboardGauges:  stationDashboardGauges,
+		moduleGauges:            moduleGauges,
+		moduleFirmwareInfo:      moduleFirmwareInfo,
+		moduleDashboardGauges:   moduleDashboardGauges,
+		indoorModuleGauges:      indoorModuleGauges,
+		outdoorModuleGauges:     outdoorModuleGauges,
+		windModuleGauges:        windModuleGauges,
+		rainModuleGauges:        rainModuleGauges,
+		indoorExtraModuleGauges: indoorExtraModuleGauges,
+		indoorModuleTrends:      indoorModuleTrends,
+		outdoorModuleTrends:     outdoorModuleTrends,
+		indoorExtraModuleTrends: indoorExtraModuleTrends,
 	}
 }
 
+func newTrendCollectors(namespace, subsystem, name string, labels []string) (collector, info *prometheus.GaugeVec) {
+	collector = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      name,
+	}, labels)
+	info = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      name + "_info",
+		Help:      "Trend as label, value is always 1.",
+	}, append(labels[:len(labels):len(labels)], "trend"))
+	return collector, info
+}
+
 func (sd *StationsData) forEach(f func(c prometheus.Collector)) {
+	for _, g := range sd.stationGauges {
+		f(g.collector)
+	}
+	f(sd.stationFirmwareInfo)
+	for _, g := range sd.stationDashboardGauges {
+		f(g.collector)
+	}
+	for _, g := range sd.moduleGauges {
+		f(g.collector)
+	}
+	f(sd.moduleFirmwareInfo)
+	for _, g := range sd.moduleDashboardGauges {
+		f(g.collector)
+	}
 	for _, g := range sd.indoorModuleGauges {
 		f(g.collector)
 	}
@@ -145,6 +493,24 @@ func (sd *StationsData) forEach(f func(c prometheus.Collector)) {
 	for _, g := range sd.windModuleGauges {
 		f(g.collector)
 	}
+	for _, g := range sd.rainModuleGauges {
+		f(g.collector)
+	}
+	for _, g := range sd.indoorExtraModuleGauges {
+		f(g.collector)
+	}
+	for _, t := range sd.indoorModuleTrends {
+		f(t.collector)
+		f(t.info)
+	}
+	for _, t := range sd.outdoorModuleTrends {
+		f(t.collector)
+		f(t.info)
+	}
+	for _, t := range sd.indoorExtraModuleTrends {
+		f(t.collector)
+		f(t.info)
+	}
 }
 
 func (sd *StationsData) Describe(d chan<- *prometheus.Desc) {
@@ -154,9 +520,7 @@ func (sd *StationsData) Describe(d chan<- *prometheus.Desc) {
 }
 
 func (sd *StationsData) Collect(m chan<- prometheus.Metric) {
-	sd.forEach(func(c prometheus.Collector) {
-		c.Collect(m)
-	})
+	sd.series.Collect(m)
 }
 
 func (sd *StationsData) Run(ctx context.Context) {
@@ -178,6 +542,7 @@ func (sd *StationsData) Update() {
 	stationsData, err := sd.client.StationsData()
 	if err != nil {
 		sd.log.Printf("Error fetching stations data: %s", err)
+		sd.Health.Observe(start, err)
 		return
 	}
 
@@ -190,12 +555,30 @@ func (sd *StationsData) Update() {
 			"station_name": device.StationName,
 		}
 
-		for _, g := range sd.indoorModuleGauges {
-			val := g.value(&device.DashboardData.IndoorModuleData)
-			g.collector.With(stationLabels).Set(val)
+		for _, g := range sd.stationGauges {
+			sd.series.Set(g.collector, stationLabels, g.value(&device))
 		}
+		sd.series.SetInfo(sd.stationFirmwareInfo, stationLabels, "firmware", strconv.Itoa(device.Firmware), time.Time{})
 
-		sd.log.Printf("Processed dashboard data of %s device %s (%s)", device.Type, device.StationName, device.ID)
+		// Unreachable station has no dashboard data, its measurements are left to become stale.
+		if device.Reachable {
+			measuredAt := device.DashboardData.TimeUtc
+			for _, g := range sd.stationDashboardGauges {
+				sd.setMeasurement(g.collector, stationLabels, g.value(&device), measuredAt)
+			}
+			for _, g := range sd.indoorModuleGauges {
+				val := g.value(&device.DashboardData.IndoorModuleData)
+				sd.setMeasurement(g.collector, stationLabels, val, measuredAt)
+			}
+			for _, t := range sd.indoorModuleTrends {
+				trend := t.value(&device.DashboardData.IndoorModuleData)
+				sd.setTrend(t.collector, t.info, stationLabels, trend, measuredAt)
+			}
+
+			sd.log.Printf("Processed dashboard data of %s device %s (%s)", device.Type, device.StationName, device.ID)
+		} else {
+			sd.log.Printf("Device %s %s (%s) is unreachable", device.Type, device.StationName, device.ID)
+		}
 
 		for _, module := range device.Modules {
 			moduleLabels := prometheus.Labels{
@@ -206,16 +589,49 @@ func (sd *StationsData) Update() {
 				"module_name": module.ModuleName,
 			}
 
+			for _, g := range sd.moduleGauges {
+				sd.series.Set(g.collector, moduleLabels, g.value(&module))
+			}
+			sd.series.SetInfo(sd.moduleFirmwareInfo, moduleLabels, "firmware", strconv.Itoa(module.Firmware), time.Time{})
+
+			if !module.Reachable {
+				sd.log.Printf("Module %s %s (%s) is unreachable", module.Type, module.ModuleName, module.ID)
+				continue
+			}
+
+			measuredAt := module.DashboardData.TimeUtc
+			for _, g := range sd.moduleDashboardGauges {
+				sd.setMeasurement(g.collector, moduleLabels, g.value(&module), measuredAt)
+			}
+
 			switch module.Type {
 			case DeviceTypeOutdoor:
 				for _, g := range sd.outdoorModuleGauges {
 					val := g.value(&module.DashboardData.OutdoorModuleData)
-					g.collector.With(moduleLabels).Set(val)
+					sd.setMeasurement(g.collector, moduleLabels, val, measuredAt)
+				}
+				for _, t := range sd.outdoorModuleTrends {
+					trend := t.value(&module.DashboardData.OutdoorModuleData)
+					sd.setTrend(t.collector, t.info, moduleLabels, trend, measuredAt)
 				}
 			case DeviceTypeWind:
 				for _, g := range sd.windModuleGauges {
 					val := g.value(&module.DashboardData.WindModuleData)
-					g.collector.With(moduleLabels).Set(val)
+					sd.setMeasurement(g.collector, moduleLabels, val, measuredAt)
+				}
+			case DeviceTypeRain:
+				for _, g := range sd.rainModuleGauges {
+					val := g.value(&module.DashboardData.RainModuleData)
+					sd.setMeasurement(g.collector, moduleLabels, val, measuredAt)
+				}
+			case DeviceTypeIndoorExtra:
+				for _, g := range sd.indoorExtraModuleGauges {
+					val := g.value(&module.DashboardData.IndoorExtraModuleData)
+					sd.setMeasurement(g.collector, moduleLabels, val, measuredAt)
+				}
+				for _, t := range sd.indoorExtraModuleTrends {
+					trend := t.value(&module.DashboardData.OutdoorModuleData)
+					sd.setTrend(t.collector, t.info, moduleLabels, trend, measuredAt)
 				}
 			default:
 				sd.log.Printf("Unsupported module type: %s", module.Type)
@@ -225,6 +641,52 @@ func (sd *StationsData) Update() {
 		}
 	}
 
+	if deleted := sd.series.DeleteStale(start.Add(-time.Duration(sd.config.StaleGracePeriod))); deleted > 0 {
+		sd.log.Printf("Deleted %d stale series of stations data", deleted)
+	}
+
+	sd.Health.Observe(start)
+
 	duration := time.Since(start)
 	sd.log.Println("Updated stations data successfully, took", duration)
 }
+
+// setMeasurement sets gauge of dashboard data.
+// Measurement time is attached as sample timestamp if it is enabled in config.
+// Missing time would stamp the sample with 1970, which Prometheus rejects, so such sample has no timestamp.
+func (sd *StationsData) setMeasurement(vec *prometheus.GaugeVec, labels prometheus.Labels, value float64, timeUtc int) {
+	sd.series.SetWithTimestamp(vec, labels, value, sd.sampleTimestamp(timeUtc))
+}
+
+func (sd *StationsData) sampleTimestamp(timeUtc int) time.Time {
+	if sd.config.SampleTimestamps && timeUtc != 0 {
+		return time.Unix(int64(timeUtc), 0)
+	}
+	return time.Time{}
+}
+
+// setTrend sets numeric trend gauge and its info metric.
+// Unknown trend, for example missing one, is not exported.
+func (sd *StationsData) setTrend(collector, info *prometheus.GaugeVec, labels prometheus.Labels, trend string, timeUtc int) {
+	var val float64
+	switch trend {
+	case TrendDown:
+		val = -1
+	case TrendStable:
+		val = 0
+	case TrendUp:
+		val = 1
+	default:
+		return
+	}
+	sd.setMeasurement(collector, labels, val, timeUtc)
+	// Info series of previous trend is deleted, so only one trend is reported at a time.
+	sd.series.SetInfo(info, labels, "trend", trend, sd.sampleTimestamp(timeUtc))
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
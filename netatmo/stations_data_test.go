@@ -1,12 +1,15 @@
 package netatmo_test
 
 import (
+	"encoding/json"
 	"log"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/require"
 	"github.com/ulexxander/weather-prometheus-exporters/config"
@@ -46,93 +49,479 @@ func TestStationsData(t *testing.T) {
 	gatheredMetrics, err := reg.Gather()
 	require.NoError(t, err)
 
-	sptr := func(s string) *string { return &s }
-	fptr := func(f float64) *float64 { return &f }
-
-	indoorModuleMetric := func(name string, value float64) *dto.MetricFamily {
-		return &dto.MetricFamily{
-			Name: sptr("netatmo_indoor_module_" + name),
-			Type: dto.MetricType_GAUGE.Enum(),
-			Help: sptr(""),
-			Metric: []*dto.Metric{
-				{
-					Label: []*dto.LabelPair{
-						{Name: sptr("home_id"), Value: sptr("61b646afb535277ce721d1a4")},
-						{Name: sptr("home_name"), Value: sptr("My home")},
-						{Name: sptr("id"), Value: sptr("70:ee:50:80:26:fa")},
-						{Name: sptr("station_name"), Value: sptr("My home (Indoor)")},
-						{Name: sptr("type"), Value: sptr("NAMain")},
-					},
-					Gauge: &dto.Gauge{
-						Value: fptr(value),
-					},
-				},
-			},
-		}
+	expectedMetrics := []*dto.MetricFamily{
+		gaugeFamily("netatmo_indoor_extra_module_co2", indoorExtraModuleMetric(968)),
+		gaugeFamily("netatmo_indoor_extra_module_humidity", indoorExtraModuleMetric(54)),
+		gaugeFamily("netatmo_indoor_extra_module_max_temp", indoorExtraModuleMetric(23.5)),
+		gaugeFamily("netatmo_indoor_extra_module_max_temp_timestamp_seconds", indoorExtraModuleMetric(1651466402)),
+		gaugeFamily("netatmo_indoor_extra_module_min_temp", indoorExtraModuleMetric(21.1)),
+		gaugeFamily("netatmo_indoor_extra_module_min_temp_timestamp_seconds", indoorExtraModuleMetric(1651449011)),
+		gaugeFamily("netatmo_indoor_extra_module_temp_trend", indoorExtraModuleMetric(1)),
+		infoFamily("netatmo_indoor_extra_module_temp_trend_info", "Trend as label, value is always 1.", withTrend(indoorExtraModuleMetric(1), "up")),
+		gaugeFamily("netatmo_indoor_extra_module_temperature", indoorExtraModuleMetric(22.4)),
+		gaugeFamily("netatmo_indoor_module_absolute_pressure", stationMetric(965.5)),
+		gaugeFamily("netatmo_indoor_module_co2", stationMetric(762)),
+		gaugeFamily("netatmo_indoor_module_humidity", stationMetric(49)),
+		gaugeFamily("netatmo_indoor_module_max_temp", stationMetric(28)),
+		gaugeFamily("netatmo_indoor_module_max_temp_timestamp_seconds", stationMetric(1651475120)),
+		gaugeFamily("netatmo_indoor_module_min_temp", stationMetric(18.8)),
+		gaugeFamily("netatmo_indoor_module_min_temp_timestamp_seconds", stationMetric(1651465946)),
+		gaugeFamily("netatmo_indoor_module_noise", stationMetric(50)),
+		gaugeFamily("netatmo_indoor_module_pressure", stationMetric(1012)),
+		gaugeFamily("netatmo_indoor_module_pressure_trend", stationMetric(-1)),
+		infoFamily("netatmo_indoor_module_pressure_trend_info", "Trend as label, value is always 1.", withTrend(stationMetric(1), "down")),
+		gaugeFamily("netatmo_indoor_module_temp_trend", stationMetric(-1)),
+		infoFamily("netatmo_indoor_module_temp_trend_info", "Trend as label, value is always 1.", withTrend(stationMetric(1), "down")),
+		gaugeFamily("netatmo_indoor_module_temperature", stationMetric(20.9)),
+		gaugeFamily("netatmo_module_battery_percent",
+			outdoorModuleMetric(100),
+			indoorExtraModuleMetric(64),
+			rainModuleMetric(86),
+			windModuleMetric(100),
+		),
+		gaugeFamily("netatmo_module_battery_voltage",
+			outdoorModuleMetric(6.312),
+			indoorExtraModuleMetric(5.41),
+			rainModuleMetric(5.842),
+			windModuleMetric(6.285),
+		),
+		infoFamily("netatmo_module_firmware_info", "Firmware version of the module, value is always 1.",
+			withFirmware(rainModuleMetric(1), "12"),
+			withFirmware(windModuleMetric(1), "25"),
+			withFirmware(outdoorModuleMetric(1), "50"),
+			withFirmware(indoorExtraModuleMetric(1), "50"),
+		),
+		gaugeFamily("netatmo_module_last_message_timestamp_seconds",
+			outdoorModuleMetric(1651477539),
+			indoorExtraModuleMetric(1651477539),
+			rainModuleMetric(1651477539),
+			windModuleMetric(1651477539),
+		),
+		gaugeFamily("netatmo_module_last_seen_timestamp_seconds",
+			outdoorModuleMetric(1651477494),
+			indoorExtraModuleMetric(1651477520),
+			rainModuleMetric(1651477533),
+			windModuleMetric(1651477539),
+		),
+		gaugeFamily("netatmo_module_measurement_timestamp_seconds",
+			outdoorModuleMetric(1651477494),
+			indoorExtraModuleMetric(1651477520),
+			rainModuleMetric(1651477533),
+			windModuleMetric(1651477539),
+		),
+		gaugeFamily("netatmo_module_reachable",
+			outdoorModuleMetric(1),
+			indoorExtraModuleMetric(1),
+			rainModuleMetric(1),
+			windModuleMetric(1),
+		),
+		gaugeFamily("netatmo_module_rf_status",
+			outdoorModuleMetric(87),
+			indoorExtraModuleMetric(55),
+			rainModuleMetric(68),
+			windModuleMetric(74),
+		),
+		gaugeFamily("netatmo_outdoor_module_humidity", outdoorModuleMetric(91)),
+		gaugeFamily("netatmo_outdoor_module_max_temp", outdoorModuleMetric(20.8)),
+		gaugeFamily("netatmo_outdoor_module_max_temp_timestamp_seconds", outdoorModuleMetric(1651475085)),
+		gaugeFamily("netatmo_outdoor_module_min_temp", outdoorModuleMetric(11.9)),
+		gaugeFamily("netatmo_outdoor_module_min_temp_timestamp_seconds", outdoorModuleMetric(1651477494)),
+		gaugeFamily("netatmo_outdoor_module_temp_trend", outdoorModuleMetric(-1)),
+		infoFamily("netatmo_outdoor_module_temp_trend_info", "Trend as label, value is always 1.", withTrend(outdoorModuleMetric(1), "down")),
+		gaugeFamily("netatmo_outdoor_module_temperature", outdoorModuleMetric(11.9)),
+		gaugeFamily("netatmo_rain_module_rain", rainModuleMetric(0.303)),
+		gaugeFamily("netatmo_rain_module_sum_rain_1", rainModuleMetric(1.212)),
+		gaugeFamily("netatmo_rain_module_sum_rain_24", rainModuleMetric(4.848)),
+		gaugeFamily("netatmo_station_co2_calibrating", stationMetric(0)),
+		infoFamily("netatmo_station_firmware_info", "Firmware version of the station, value is always 1.",
+			withFirmware(stationMetric(1), "181"),
+		),
+		gaugeFamily("netatmo_station_measurement_timestamp_seconds", stationMetric(1651477543)),
+		gaugeFamily("netatmo_station_reachable", stationMetric(1)),
+		gaugeFamily("netatmo_station_wifi_status", stationMetric(41)),
+		gaugeFamily("netatmo_wind_module_gust_angle", windModuleMetric(23)),
+		gaugeFamily("netatmo_wind_module_gust_strength", windModuleMetric(5)),
+		gaugeFamily("netatmo_wind_module_max_wind_angle", windModuleMetric(355)),
+		gaugeFamily("netatmo_wind_module_max_wind_str", windModuleMetric(11)),
+		gaugeFamily("netatmo_wind_module_max_wind_str_timestamp_seconds", windModuleMetric(1651467760)),
+		gaugeFamily("netatmo_wind_module_wind_angle", windModuleMetric(270)),
+		gaugeFamily("netatmo_wind_module_wind_strength", windModuleMetric(1)),
 	}
 
-	outdoorModuleMetric := func(name string, value float64) *dto.MetricFamily {
-		return &dto.MetricFamily{
-			Name: sptr("netatmo_outdoor_module_" + name),
-			Type: dto.MetricType_GAUGE.Enum(),
-			Help: sptr(""),
-			Metric: []*dto.Metric{
-				{
-					Label: []*dto.LabelPair{
-						{Name: sptr("home_id"), Value: sptr("61b646afb535277ce721d1a4")},
-						{Name: sptr("home_name"), Value: sptr("My home")},
-						{Name: sptr("id"), Value: sptr("02:00:00:7f:e6:96")},
-						{Name: sptr("module_name"), Value: sptr("Zunanji modul")},
-						{Name: sptr("type"), Value: sptr("NAModule1")},
-					},
-					Gauge: &dto.Gauge{
-						Value: fptr(value),
-					},
-				},
-			},
-		}
+	require.Equal(t, expectedMetrics, gatheredMetrics)
+}
+
+func sptr(s string) *string { return &s }
+
+func fptr(f float64) *float64 { return &f }
+
+func gaugeFamily(name string, metrics ...*dto.Metric) *dto.MetricFamily {
+	return &dto.MetricFamily{
+		Name:   sptr(name),
+		Type:   dto.MetricType_GAUGE.Enum(),
+		Help:   sptr(""),
+		Metric: metrics,
 	}
+}
 
-	windModuleMetric := func(name string, value float64) *dto.MetricFamily {
-		return &dto.MetricFamily{
-			Name: sptr("netatmo_wind_module_" + name),
-			Type: dto.MetricType_GAUGE.Enum(),
-			Help: sptr(""),
-			Metric: []*dto.Metric{
-				{
-					Label: []*dto.LabelPair{
-						{Name: sptr("home_id"), Value: sptr("61b646afb535277ce721d1a4")},
-						{Name: sptr("home_name"), Value: sptr("My home")},
-						{Name: sptr("id"), Value: sptr("06:00:00:05:c6:48")},
-						{Name: sptr("module_name"), Value: sptr("Veternica")},
-						{Name: sptr("type"), Value: sptr("NAModule2")},
-					},
-					Gauge: &dto.Gauge{
-						Value: fptr(value),
-					},
-				},
-			},
+func infoFamily(name, help string, metrics ...*dto.Metric) *dto.MetricFamily {
+	family := gaugeFamily(name, metrics...)
+	family.Help = sptr(help)
+	return family
+}
+
+func withFirmware(m *dto.Metric, firmware string) *dto.Metric {
+	m.Label = append([]*dto.LabelPair{{Name: sptr("firmware"), Value: sptr(firmware)}}, m.Label...)
+	return m
+}
+
+// withTrend adds trend label, which is ordered right before the last type label.
+func withTrend(m *dto.Metric, trend string) *dto.Metric {
+	last := len(m.Label) - 1
+	labels := append([]*dto.LabelPair{}, m.Label[:last]...)
+	labels = append(labels, &dto.LabelPair{Name: sptr("trend"), Value: sptr(trend)}, m.Label[last])
+	m.Label = labels
+	return m
+}
+
+func gaugeMetric(labels []*dto.LabelPair, value float64) *dto.Metric {
+	return &dto.Metric{
+		Label: labels,
+		Gauge: &dto.Gauge{
+			Value: fptr(value),
+		},
+	}
+}
+
+func stationMetric(value float64) *dto.Metric {
+	return gaugeMetric([]*dto.LabelPair{
+		{Name: sptr("home_id"), Value: sptr("61b646afb535277ce721d1a4")},
+		{Name: sptr("home_name"), Value: sptr("My home")},
+		{Name: sptr("id"), Value: sptr("70:ee:50:80:26:fa")},
+		{Name: sptr("station_name"), Value: sptr("My home (Indoor)")},
+		{Name: sptr("type"), Value: sptr("NAMain")},
+	}, value)
+}
+
+func outdoorModuleMetric(value float64) *dto.Metric {
+	return gaugeMetric([]*dto.LabelPair{
+		{Name: sptr("home_id"), Value: sptr("61b646afb535277ce721d1a4")},
+		{Name: sptr("home_name"), Value: sptr("My home")},
+		{Name: sptr("id"), Value: sptr("02:00:00:7f:e6:96")},
+		{Name: sptr("module_name"), Value: sptr("Zunanji modul")},
+		{Name: sptr("type"), Value: sptr("NAModule1")},
+	}, value)
+}
+
+func rainModuleMetric(value float64) *dto.Metric {
+	return gaugeMetric([]*dto.LabelPair{
+		{Name: sptr("home_id"), Value: sptr("61b646afb535277ce721d1a4")},
+		{Name: sptr("home_name"), Value: sptr("My home")},
+		{Name: sptr("id"), Value: sptr("05:00:00:0a:b1:c2")},
+		{Name: sptr("module_name"), Value: sptr("Dezemer")},
+		{Name: sptr("type"), Value: sptr("NAModule3")},
+	}, value)
+}
+
+func indoorExtraModuleMetric(value float64) *dto.Metric {
+	return gaugeMetric([]*dto.LabelPair{
+		{Name: sptr("home_id"), Value: sptr("61b646afb535277ce721d1a4")},
+		{Name: sptr("home_name"), Value: sptr("My home")},
+		{Name: sptr("id"), Value: sptr("03:00:00:0b:12:34")},
+		{Name: sptr("module_name"), Value: sptr("Spalnica")},
+		{Name: sptr("type"), Value: sptr("NAModule4")},
+	}, value)
+}
+
+func windModuleMetric(value float64) *dto.Metric {
+	return gaugeMetric([]*dto.LabelPair{
+		{Name: sptr("home_id"), Value: sptr("61b646afb535277ce721d1a4")},
+		{Name: sptr("home_name"), Value: sptr("My home")},
+		{Name: sptr("id"), Value: sptr("06:00:00:05:c6:48")},
+		{Name: sptr("module_name"), Value: sptr("Veternica")},
+		{Name: sptr("type"), Value: sptr("NAModule2")},
+	}, value)
+}
+
+func TestStationsData_StaleSeries(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := netatmo.NewClient(oauth)
+	client.URL = server.URL
+
+	stationsData := netatmo.NewStationsData(client, &config.NetatmoStationsData{}, log.Default())
+
+	reg := prometheus.NewRegistry()
+	err := reg.Register(stationsData)
+	require.NoError(t, err)
+
+	update := func(response interface{}) {
+		updated := make(chan struct{})
+		go func() {
+			stationsData.Update()
+			updated <- struct{}{}
+		}()
+
+		select {
+		case <-handler.Requests:
+			handler.Responses <- response
+		case <-time.After(time.Second):
+			require.Fail(t, "request did not arrived")
 		}
+
+		<-updated
 	}
 
+	update([]byte(response))
+
+	var res netatmo.StationsDataResponse
+	err = json.Unmarshal([]byte(response), &res)
+	require.NoError(t, err)
+
+	// Wind, rain and indoor extra modules were removed, outdoor module went offline and lost its dashboard data.
+	device := &res.Body.Devices[0]
+	device.Modules = device.Modules[1:2]
+	outdoorModule := &device.Modules[0]
+	outdoorModule.Reachable = false
+	outdoorModule.DashboardData.OutdoorModuleData = netatmo.OutdoorModuleData{}
+
+	update(res)
+
+	gatheredMetrics, err := reg.Gather()
+	require.NoError(t, err)
+
 	expectedMetrics := []*dto.MetricFamily{
-		indoorModuleMetric("absolute_pressure", 965.5),
-		indoorModuleMetric("co2", 762),
-		indoorModuleMetric("humidity", 49),
-		indoorModuleMetric("noise", 50),
-		indoorModuleMetric("pressure", 1012),
-		indoorModuleMetric("temperature", 20.9),
-		outdoorModuleMetric("humidity", 91),
-		outdoorModuleMetric("temperature", 11.9),
-		windModuleMetric("gust_angle", 23),
-		windModuleMetric("gust_strength", 5),
-		windModuleMetric("wind_angle", 270),
-		windModuleMetric("wind_strength", 1),
+		gaugeFamily("netatmo_indoor_module_absolute_pressure", stationMetric(965.5)),
+		gaugeFamily("netatmo_indoor_module_co2", stationMetric(762)),
+		gaugeFamily("netatmo_indoor_module_humidity", stationMetric(49)),
+		gaugeFamily("netatmo_indoor_module_max_temp", stationMetric(28)),
+		gaugeFamily("netatmo_indoor_module_max_temp_timestamp_seconds", stationMetric(1651475120)),
+		gaugeFamily("netatmo_indoor_module_min_temp", stationMetric(18.8)),
+		gaugeFamily("netatmo_indoor_module_min_temp_timestamp_seconds", stationMetric(1651465946)),
+		gaugeFamily("netatmo_indoor_module_noise", stationMetric(50)),
+		gaugeFamily("netatmo_indoor_module_pressure", stationMetric(1012)),
+		gaugeFamily("netatmo_indoor_module_pressure_trend", stationMetric(-1)),
+		infoFamily("netatmo_indoor_module_pressure_trend_info", "Trend as label, value is always 1.", withTrend(stationMetric(1), "down")),
+		gaugeFamily("netatmo_indoor_module_temp_trend", stationMetric(-1)),
+		infoFamily("netatmo_indoor_module_temp_trend_info", "Trend as label, value is always 1.", withTrend(stationMetric(1), "down")),
+		gaugeFamily("netatmo_indoor_module_temperature", stationMetric(20.9)),
+		gaugeFamily("netatmo_module_battery_percent", outdoorModuleMetric(100)),
+		gaugeFamily("netatmo_module_battery_voltage", outdoorModuleMetric(6.312)),
+		infoFamily("netatmo_module_firmware_info", "Firmware version of the module, value is always 1.",
+			withFirmware(outdoorModuleMetric(1), "50"),
+		),
+		gaugeFamily("netatmo_module_last_message_timestamp_seconds", outdoorModuleMetric(1651477539)),
+		gaugeFamily("netatmo_module_last_seen_timestamp_seconds", outdoorModuleMetric(1651477494)),
+		gaugeFamily("netatmo_module_reachable", outdoorModuleMetric(0)),
+		gaugeFamily("netatmo_module_rf_status", outdoorModuleMetric(87)),
+		gaugeFamily("netatmo_station_co2_calibrating", stationMetric(0)),
+		infoFamily("netatmo_station_firmware_info", "Firmware version of the station, value is always 1.",
+			withFirmware(stationMetric(1), "181"),
+		),
+		gaugeFamily("netatmo_station_measurement_timestamp_seconds", stationMetric(1651477543)),
+		gaugeFamily("netatmo_station_reachable", stationMetric(1)),
+		gaugeFamily("netatmo_station_wifi_status", stationMetric(41)),
 	}
 
 	require.Equal(t, expectedMetrics, gatheredMetrics)
 }
 
+func TestStationsData_ChangedInfo(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := netatmo.NewClient(oauth)
+	client.URL = server.URL
+
+	// Grace period keeps stale series, but series of previous trend and firmware are replaced right away.
+	stationsData := netatmo.NewStationsData(client, &config.NetatmoStationsData{StaleGracePeriod: config.Duration(time.Hour)}, log.Default())
+
+	reg := prometheus.NewRegistry()
+	err := reg.Register(stationsData)
+	require.NoError(t, err)
+
+	update := func(response interface{}) {
+		updated := make(chan struct{})
+		go func() {
+			stationsData.Update()
+			updated <- struct{}{}
+		}()
+
+		select {
+		case <-handler.Requests:
+			handler.Responses <- response
+		case <-time.After(time.Second):
+			require.Fail(t, "request did not arrived")
+		}
+
+		<-updated
+	}
+
+	update([]byte(response))
+
+	var res netatmo.StationsDataResponse
+	err = json.Unmarshal([]byte(response), &res)
+	require.NoError(t, err)
+	device := &res.Body.Devices[0]
+	device.DashboardData.TempTrend = netatmo.TrendStable
+	device.Firmware = 182
+
+	update(res)
+
+	gatheredMetrics, err := reg.Gather()
+	require.NoError(t, err)
+
+	families := map[string]*dto.MetricFamily{}
+	for _, family := range gatheredMetrics {
+		families[family.GetName()] = family
+	}
+	require.Equal(t,
+		infoFamily("netatmo_indoor_module_temp_trend_info", "Trend as label, value is always 1.", withTrend(stationMetric(1), "stable")),
+		families["netatmo_indoor_module_temp_trend_info"],
+	)
+	require.Equal(t,
+		infoFamily("netatmo_station_firmware_info", "Firmware version of the station, value is always 1.", withFirmware(stationMetric(1), "182")),
+		families["netatmo_station_firmware_info"],
+	)
+}
+
+func TestStationsData_SampleTimestamps(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := netatmo.NewClient(oauth)
+	client.URL = server.URL
+
+	stationsData := netatmo.NewStationsData(client, &config.NetatmoStationsData{SampleTimestamps: true}, log.Default())
+
+	reg := prometheus.NewRegistry()
+	err := reg.Register(stationsData)
+	require.NoError(t, err)
+
+	updated := make(chan struct{})
+	go func() {
+		stationsData.Update()
+		updated <- struct{}{}
+	}()
+
+	select {
+	case <-handler.Requests:
+		handler.Responses <- []byte(response)
+	case <-time.After(time.Second):
+		require.Fail(t, "request did not arrived")
+	}
+
+	<-updated
+
+	gatheredMetrics, err := reg.Gather()
+	require.NoError(t, err)
+
+	timestamps := map[string][]int64{}
+	for _, family := range gatheredMetrics {
+		for _, m := range family.Metric {
+			timestamps[family.GetName()] = append(timestamps[family.GetName()], m.GetTimestampMs())
+		}
+	}
+
+	require.Equal(t, []int64{1651477543000}, timestamps["netatmo_indoor_module_temperature"])
+	require.Equal(t, []int64{1651477494000}, timestamps["netatmo_outdoor_module_temperature"])
+	require.Equal(t, []int64{1651477539000}, timestamps["netatmo_wind_module_wind_strength"])
+	require.Equal(t, []int64{1651477533000}, timestamps["netatmo_rain_module_rain"])
+	require.Equal(t, []int64{1651477520000}, timestamps["netatmo_indoor_extra_module_co2"])
+	require.Equal(t, []int64{0, 0, 0, 0}, timestamps["netatmo_module_reachable"])
+}
+
+func TestStationsData_SampleTimestampsMissingTime(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := netatmo.NewClient(oauth)
+	client.URL = server.URL
+
+	stationsData := netatmo.NewStationsData(client, &config.NetatmoStationsData{SampleTimestamps: true}, log.Default())
+
+	reg := prometheus.NewRegistry()
+	err := reg.Register(stationsData)
+	require.NoError(t, err)
+
+	updated := make(chan struct{})
+	go func() {
+		stationsData.Update()
+		updated <- struct{}{}
+	}()
+
+	// Outdoor module has no measurement time.
+	select {
+	case <-handler.Requests:
+		handler.Responses <- []byte(strings.Replace(response, `"time_utc": 1651477494,`, "", 1))
+	case <-time.After(time.Second):
+		require.Fail(t, "request did not arrived")
+	}
+
+	<-updated
+
+	gatheredMetrics, err := reg.Gather()
+	require.NoError(t, err)
+
+	timestamps := map[string]*int64{}
+	for _, family := range gatheredMetrics {
+		timestamps[family.GetName()] = family.Metric[0].TimestampMs
+	}
+
+	require.Nil(t, timestamps["netatmo_outdoor_module_temperature"])
+	require.Equal(t, int64(1651477543000), *timestamps["netatmo_indoor_module_temperature"])
+}
+
+func TestStationsData_Error(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := netatmo.NewClient(oauth)
+	client.URL = server.URL
+
+	stationsData := netatmo.NewStationsData(client, &config.NetatmoStationsData{}, log.Default())
+
+	updated := make(chan struct{})
+	go func() {
+		stationsData.Update()
+		updated <- struct{}{}
+	}()
+
+	select {
+	case <-handler.Requests:
+		handler.Responses <- netatmo.ErrorResponse{
+			Error: &netatmo.Error{
+				Code:    3,
+				Message: "Access token expired",
+			},
+		}
+	case <-time.After(time.Second):
+		require.Fail(t, "request did not arrived")
+	}
+
+	<-updated
+
+	expected := `
+# HELP netatmo_stations_data_up Whether the last update succeeded.
+# TYPE netatmo_stations_data_up gauge
+netatmo_stations_data_up 0
+# HELP netatmo_stations_data_update_errors_total Errors occurred during updates by class and API error code.
+# TYPE netatmo_stations_data_update_errors_total counter
+netatmo_stations_data_update_errors_total{class="api",code="3"} 1
+`
+	err := promtestutil.CollectAndCompare(stationsData.Health, strings.NewReader(expected),
+		"netatmo_stations_data_up",
+		"netatmo_stations_data_update_errors_total",
+	)
+	require.NoError(t, err)
+}
+
 const response = `{
   "body": {
     "devices": [
@@ -220,6 +609,51 @@ const response = `{
               "date_min_temp": 1651477494,
               "temp_trend": "down"
             }
+          },
+          {
+            "_id": "05:00:00:0a:b1:c2",
+            "type": "NAModule3",
+            "module_name": "Dezemer",
+            "last_setup": 1651475120,
+            "data_type": ["Rain"],
+            "battery_percent": 86,
+            "reachable": true,
+            "firmware": 12,
+            "last_message": 1651477539,
+            "last_seen": 1651477533,
+            "rf_status": 68,
+            "battery_vp": 5842,
+            "dashboard_data": {
+              "time_utc": 1651477533,
+              "Rain": 0.303,
+              "sum_rain_1": 1.212,
+              "sum_rain_24": 4.848
+            }
+          },
+          {
+            "_id": "03:00:00:0b:12:34",
+            "type": "NAModule4",
+            "module_name": "Spalnica",
+            "last_setup": 1651475210,
+            "data_type": ["Temperature", "CO2", "Humidity"],
+            "battery_percent": 64,
+            "reachable": true,
+            "firmware": 50,
+            "last_message": 1651477539,
+            "last_seen": 1651477520,
+            "rf_status": 55,
+            "battery_vp": 5410,
+            "dashboard_data": {
+              "time_utc": 1651477520,
+              "Temperature": 22.4,
+              "CO2": 968,
+              "Humidity": 54,
+              "min_temp": 21.1,
+              "max_temp": 23.5,
+              "date_max_temp": 1651466402,
+              "date_min_temp": 1651449011,
+              "temp_trend": "up"
+            }
           }
         ]
       }
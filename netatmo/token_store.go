@@ -0,0 +1,126 @@
+package netatmo
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// TokenStore keeps the latest token pair, so it survives restarts.
+type TokenStore interface {
+	Load() (*OAuthTokenResponse, error)
+	Save(token *OAuthTokenResponse) error
+}
+
+// FileTokenStore keeps token pair in JSON file.
+type FileTokenStore struct {
+	Path string
+}
+
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{Path: path}
+}
+
+func (fs *FileTokenStore) Load() (*OAuthTokenResponse, error) {
+	content, err := os.ReadFile(fs.Path)
+	if err != nil {
+		return nil, fmt.Errorf("reading token file: %w", err)
+	}
+	var token OAuthTokenResponse
+	if err := json.Unmarshal(content, &token); err != nil {
+		return nil, fmt.Errorf("unmarshaling token file: %w", err)
+	}
+	return &token, nil
+}
+
+// Save writes token pair into temporary file and renames it afterwards,
+// so token file is never left half-written.
+func (fs *FileTokenStore) Save(token *OAuthTokenResponse) error {
+	content, err := json.MarshalIndent(token, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling token: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(fs.Path), filepath.Base(fs.Path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("creating temporary token file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing temporary token file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("closing temporary token file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), fs.Path); err != nil {
+		return fmt.Errorf("renaming temporary token file: %w", err)
+	}
+	return nil
+}
+
+type storedOAuth struct {
+	oauth
+	Store TokenStore
+	Log   *log.Logger
+
+	mu sync.Mutex
+	// unsaved is the latest token pair that store failed to save.
+	// Previous refresh token is revoked by then, so this one is used instead of stored one until saving succeeds.
+	unsaved *OAuthTokenResponse
+}
+
+// NewStoredOAuth creates OAuth that obtains access tokens by refreshing token pair from the store.
+// Refresh tokens are rotated by Netatmo, so every new pair is saved back into the store.
+// Store has to be bootstrapped with token pair obtained using authorization code grant type, see Exchange.
+func NewStoredOAuth(clientID, clientSecret string, store TokenStore) *storedOAuth {
+	return &storedOAuth{
+		oauth: oauth{
+			URL:          DefaultOAuthURL,
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+		},
+		Store: store,
+		Log:   log.Default(),
+	}
+}
+
+// Token refreshes token pair kept in the store.
+// Scope can not be changed on refresh, it is defined by the authorization code that store was bootstrapped with.
+func (so *storedOAuth) Token(scope string) (*OAuthTokenResponse, error) {
+	so.mu.Lock()
+	unsaved := so.unsaved
+	so.mu.Unlock()
+	if unsaved != nil {
+		return so.Refresh(unsaved.RefreshToken)
+	}
+
+	stored, err := so.Store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("loading stored token: %w", err)
+	}
+	return so.Refresh(stored.RefreshToken)
+}
+
+// Refresh returns refreshed token pair even when saving it fails,
+// it is kept in memory and saving is retried with the next refresh.
+func (so *storedOAuth) Refresh(refreshToken string) (*OAuthTokenResponse, error) {
+	token, err := so.oauth.Refresh(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	so.mu.Lock()
+	defer so.mu.Unlock()
+	if err := so.Store.Save(token); err != nil {
+		so.Log.Println("Error saving refreshed Netatmo token, it is kept in memory until saving succeeds:", err)
+		so.unsaved = token
+		return token, nil
+	}
+	so.unsaved = nil
+	return token, nil
+}
@@ -0,0 +1,167 @@
+package netatmo_test
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ulexxander/weather-prometheus-exporters/netatmo"
+	"github.com/ulexxander/weather-prometheus-exporters/testutil"
+)
+
+func TestStoredOAuth(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	store := netatmo.NewFileTokenStore(filepath.Join(t.TempDir(), "token.json"))
+	err := store.Save(&netatmo.OAuthTokenResponse{
+		AccessToken:  "old-access",
+		ExpiresIn:    10800,
+		RefreshToken: "old-refresh",
+	})
+	require.NoError(t, err)
+
+	oauth := netatmo.NewStoredOAuth("my-clientID", "my-clientSecret", store)
+	oauth.URL = server.URL
+
+	type requestResult struct {
+		token *netatmo.OAuthTokenResponse
+		err   error
+	}
+	resultChan := make(chan requestResult)
+	go func() {
+		token, err := oauth.Token("my-scope")
+		resultChan <- requestResult{token, err}
+	}()
+
+	var r *http.Request
+	select {
+	case r = <-handler.Requests:
+	case <-time.After(time.Second):
+		require.Fail(t, "request did not arrived")
+	}
+
+	require.Equal(t, "/token", r.URL.Path)
+
+	bodyRaw, err := io.ReadAll(r.Body)
+	require.NoError(t, err)
+
+	body, err := url.ParseQuery(string(bodyRaw))
+	require.NoError(t, err)
+
+	expectedBody := url.Values{}
+	expectedBody.Set("grant_type", "refresh_token")
+	expectedBody.Set("client_id", "my-clientID")
+	expectedBody.Set("client_secret", "my-clientSecret")
+	expectedBody.Set("refresh_token", "old-refresh")
+	require.Equal(t, expectedBody, body)
+
+	response := netatmo.OAuthTokenResponse{
+		AccessToken:  "new-access",
+		ExpiresIn:    10800,
+		RefreshToken: "new-refresh",
+	}
+	handler.Responses <- response
+
+	var result requestResult
+	select {
+	case result = <-resultChan:
+	case <-time.After(time.Second):
+		require.Fail(t, "result did not arrived")
+	}
+
+	require.NoError(t, result.err)
+	require.Equal(t, response, *result.token)
+
+	stored, err := store.Load()
+	require.NoError(t, err)
+	require.Equal(t, response, *stored)
+}
+
+func TestStoredOAuth_NotBootstrapped(t *testing.T) {
+	store := netatmo.NewFileTokenStore(filepath.Join(t.TempDir(), "token.json"))
+	oauth := netatmo.NewStoredOAuth("my-clientID", "my-clientSecret", store)
+
+	token, err := oauth.Token("my-scope")
+	require.Error(t, err)
+	require.Nil(t, token)
+}
+
+type failingTokenStore struct {
+	token *netatmo.OAuthTokenResponse
+	saved []*netatmo.OAuthTokenResponse
+	fail  bool
+}
+
+func (s *failingTokenStore) Load() (*netatmo.OAuthTokenResponse, error) {
+	return s.token, nil
+}
+
+func (s *failingTokenStore) Save(token *netatmo.OAuthTokenResponse) error {
+	if s.fail {
+		return errors.New("disk is full")
+	}
+	s.saved = append(s.saved, token)
+	return nil
+}
+
+func TestStoredOAuth_SaveError(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	store := &failingTokenStore{
+		token: &netatmo.OAuthTokenResponse{RefreshToken: "old-refresh"},
+		fail:  true,
+	}
+	oauth := netatmo.NewStoredOAuth("my-clientID", "my-clientSecret", store)
+	oauth.URL = server.URL
+	oauth.Log = log.New(io.Discard, "", 0)
+
+	refresh := func(expectedRefreshToken string, response netatmo.OAuthTokenResponse) {
+		resultChan := make(chan *netatmo.OAuthTokenResponse)
+		go func() {
+			token, err := oauth.Token("my-scope")
+			require.NoError(t, err)
+			resultChan <- token
+		}()
+
+		select {
+		case r := <-handler.Requests:
+			bodyRaw, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			body, err := url.ParseQuery(string(bodyRaw))
+			require.NoError(t, err)
+			require.Equal(t, expectedRefreshToken, body.Get("refresh_token"))
+		case <-time.After(time.Second):
+			require.Fail(t, "request did not arrived")
+		}
+		handler.Responses <- response
+
+		select {
+		case token := <-resultChan:
+			require.Equal(t, response, *token)
+		case <-time.After(time.Second):
+			require.Fail(t, "result did not arrived")
+		}
+	}
+
+	// Refreshed token is returned although it could not be saved.
+	first := netatmo.OAuthTokenResponse{AccessToken: "access-1", RefreshToken: "refresh-1"}
+	refresh("old-refresh", first)
+	require.Empty(t, store.saved)
+
+	// Unsaved refresh token is used instead of revoked stored one, and saved once store works again.
+	store.fail = false
+	second := netatmo.OAuthTokenResponse{AccessToken: "access-2", RefreshToken: "refresh-2"}
+	refresh("refresh-1", second)
+	require.Equal(t, []*netatmo.OAuthTokenResponse{&second}, store.saved)
+}
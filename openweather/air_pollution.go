@@ -0,0 +1,199 @@
+package openweather
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+	"github.com/ulexxander/weather-prometheus-exporters/health"
+	"github.com/ulexxander/weather-prometheus-exporters/series"
+)
+
+type airPollutionGauge struct {
+	name      string
+	help      string
+	value     func(res *AirPollutionResponse) float64
+	collector *prometheus.GaugeVec
+}
+
+// AirPollution exports Air Quality Index and concentrations of pollutants.
+type AirPollution struct {
+	// Health reports outcome of updates, it is registered separately from AirPollution.
+	Health *health.Metrics
+	client *Client
+	config *config.OpenWeatherAirPollution
+	log    *log.Logger
+	series *series.Tracker
+	// extraLabels are names of user-defined labels of all configured locations.
+	extraLabels []string
+	gauges      []airPollutionGauge
+}
+
+func NewAirPollution(
+	client *Client,
+	config *config.OpenWeatherAirPollution,
+	log *log.Logger,
+) *AirPollution {
+	const namespace = "open_weather"
+	const subsystem = "air_pollution"
+	labels := []string{"location", "lat", "lon"}
+	extraLabels := extraLabelNames(config.Coords, labels)
+	labels = append(labels, extraLabels...)
+
+	const concentrationHelp = "Concentration in μg/m3."
+	gauges := []airPollutionGauge{
+		{
+			name:  "aqi",
+			help:  "Air Quality Index from 1 (good) to 5 (very poor).",
+			value: func(res *AirPollutionResponse) float64 { return res.List[0].Main.AQI },
+		},
+		{
+			name:  "co",
+			help:  concentrationHelp,
+			value: func(res *AirPollutionResponse) float64 { return res.List[0].Components.CO },
+		},
+		{
+			name:  "no",
+			help:  concentrationHelp,
+			value: func(res *AirPollutionResponse) float64 { return res.List[0].Components.NO },
+		},
+		{
+			name:  "no2",
+			help:  concentrationHelp,
+			value: func(res *AirPollutionResponse) float64 { return res.List[0].Components.NO2 },
+		},
+		{
+			name:  "o3",
+			help:  concentrationHelp,
+			value: func(res *AirPollutionResponse) float64 { return res.List[0].Components.O3 },
+		},
+		{
+			name:  "so2",
+			help:  concentrationHelp,
+			value: func(res *AirPollutionResponse) float64 { return res.List[0].Components.SO2 },
+		},
+		{
+			name:  "pm2_5",
+			help:  concentrationHelp,
+			value: func(res *AirPollutionResponse) float64 { return res.List[0].Components.PM25 },
+		},
+		{
+			name:  "pm10",
+			help:  concentrationHelp,
+			value: func(res *AirPollutionResponse) float64 { return res.List[0].Components.PM10 },
+		},
+		{
+			name:  "nh3",
+			help:  concentrationHelp,
+			value: func(res *AirPollutionResponse) float64 { return res.List[0].Components.NH3 },
+		},
+		{
+			name:  "measurement_timestamp_seconds",
+			value: func(res *AirPollutionResponse) float64 { return float64(res.List[0].Dt) },
+		},
+	}
+
+	for i := range gauges {
+		g := &gauges[i]
+		g.collector = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      g.name,
+			Help:      g.help,
+		}, labels)
+	}
+
+	return &AirPollution{
+		Health:      health.NewMetrics(namespace, subsystem),
+		client:      client,
+		config:      config,
+		log:         log,
+		series:      series.NewTracker(),
+		extraLabels: extraLabels,
+		gauges:      gauges,
+	}
+}
+
+func (ap *AirPollution) Describe(d chan<- *prometheus.Desc) {
+	for _, g := range ap.gauges {
+		g.collector.Describe(d)
+	}
+}
+
+func (ap *AirPollution) Collect(m chan<- prometheus.Metric) {
+	ap.series.Collect(m)
+}
+
+func (ap *AirPollution) Run(ctx context.Context) {
+	ap.Update()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(ap.config.Interval)):
+			ap.Update()
+		}
+	}
+}
+
+func (ap *AirPollution) Update() {
+	type result struct {
+		coords config.Coordinates
+		res    *AirPollutionResponse
+		err    error
+	}
+
+	results := make(chan result, len(ap.config.Coords))
+	start := time.Now()
+	var errs []error
+
+	for _, coords := range ap.config.Coords {
+		go func(coords config.Coordinates) {
+			res, err := ap.client.AirPollution(location(&coords))
+			results <- result{coords, res, err}
+		}(coords)
+	}
+
+	for i := 0; i < len(ap.config.Coords); i++ {
+		result := <-results
+		if result.err != nil {
+			ap.log.Println("Error fetching Air Pollution:", result.err)
+			errs = append(errs, result.err)
+			continue
+		}
+		if len(result.res.List) == 0 {
+			ap.log.Println("No Air Pollution data for", locationName(&result.coords))
+			continue
+		}
+
+		labels := prometheus.Labels{
+			"location": result.coords.Name,
+			"lat":      strconv.FormatFloat(result.coords.Lat, 'f', -1, 64),
+			"lon":      strconv.FormatFloat(result.coords.Lon, 'f', -1, 64),
+		}
+		for _, name := range ap.extraLabels {
+			labels[name] = result.coords.Labels[name]
+		}
+
+		var measuredAt time.Time
+		if ap.config.SampleTimestamps && result.res.List[0].Dt != 0 {
+			measuredAt = time.Unix(int64(result.res.List[0].Dt), 0)
+		}
+		for _, g := range ap.gauges {
+			ap.series.SetWithTimestamp(g.collector, labels, g.value(result.res), measuredAt)
+		}
+
+		ap.log.Printf("Processed Air Pollution of %s", locationName(&result.coords))
+	}
+
+	ap.Health.Finish(health.Update{
+		Name:    "Air Pollution",
+		Start:   start,
+		Targets: len(ap.config.Coords),
+		Errs:    errs,
+	}, ap.series, time.Duration(ap.config.StaleGracePeriod), ap.log)
+}
@@ -0,0 +1,116 @@
+package openweather_test
+
+import (
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+	"github.com/ulexxander/weather-prometheus-exporters/openweather"
+	"github.com/ulexxander/weather-prometheus-exporters/testutil"
+)
+
+func TestAirPollution(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := openweather.NewClient("my-app-id")
+	client.URL = server.URL
+
+	config := &config.OpenWeatherAirPollution{
+		Coords: []config.Coordinates{
+			{
+				Lat:    46.2389,
+				Lon:    14.3556,
+				Name:   "Office",
+				Labels: map[string]string{"site": "hq"},
+			},
+		},
+	}
+	airPollution := openweather.NewAirPollution(client, config, log.Default())
+
+	reg := prometheus.NewRegistry()
+	err := reg.Register(airPollution)
+	require.NoError(t, err)
+
+	updated := make(chan struct{})
+	go func() {
+		airPollution.Update()
+		updated <- struct{}{}
+	}()
+
+	var r *http.Request
+	select {
+	case r = <-handler.Requests:
+		handler.Responses <- []byte(airPollutionResponse)
+	case <-time.After(time.Second):
+		require.Fail(t, "request did not arrived")
+	}
+
+	<-updated
+
+	require.Equal(t, "/air_pollution", r.URL.Path)
+	expectedQuery := url.Values{}
+	expectedQuery.Set("appid", "my-app-id")
+	expectedQuery.Set("lat", "46.2389")
+	expectedQuery.Set("lon", "14.3556")
+	require.Equal(t, expectedQuery, r.URL.Query())
+
+	gatheredMetrics, err := reg.Gather()
+	require.NoError(t, err)
+
+	values := map[string]float64{}
+	for _, family := range gatheredMetrics {
+		require.Len(t, family.Metric, 1, family.GetName())
+		labels := map[string]string{}
+		for _, label := range family.Metric[0].Label {
+			labels[label.GetName()] = label.GetValue()
+		}
+		require.Equal(t, map[string]string{
+			"location": "Office",
+			"lat":      "46.2389",
+			"lon":      "14.3556",
+			"site":     "hq",
+		}, labels, family.GetName())
+		values[family.GetName()] = family.Metric[0].GetGauge().GetValue()
+	}
+
+	require.Equal(t, map[string]float64{
+		"open_weather_air_pollution_aqi":                           2,
+		"open_weather_air_pollution_co":                            230.31,
+		"open_weather_air_pollution_no":                            0.12,
+		"open_weather_air_pollution_no2":                           3.34,
+		"open_weather_air_pollution_o3":                            82.25,
+		"open_weather_air_pollution_so2":                           0.61,
+		"open_weather_air_pollution_pm2_5":                         6.13,
+		"open_weather_air_pollution_pm10":                          8.47,
+		"open_weather_air_pollution_nh3":                           0.92,
+		"open_weather_air_pollution_measurement_timestamp_seconds": 1651487400,
+	}, values)
+}
+
+const airPollutionResponse = `{
+  "coord": { "lon": 14.3556, "lat": 46.2389 },
+  "list": [
+    {
+      "main": { "aqi": 2 },
+      "components": {
+        "co": 230.31,
+        "no": 0.12,
+        "no2": 3.34,
+        "o3": 82.25,
+        "so2": 0.61,
+        "pm2_5": 6.13,
+        "pm10": 8.47,
+        "nh3": 0.92
+      },
+      "dt": 1651487400
+    }
+  ]
+}`
@@ -1,29 +1,38 @@
 package openweather
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 )
 
-const DefaultURL = "https://api.openweathermap.org/data/2.5"
+const (
+	DefaultURL = "https://api.openweathermap.org/data/2.5"
+	// DefaultOneCallURL is base URL of One Call API 3.0, which is versioned separately and requires its own subscription.
+	DefaultOneCallURL = "https://api.openweathermap.org/data/3.0"
+)
 
 type Client struct {
-	URL   string
-	AppID string
+	URL        string
+	OneCallURL string
+	AppID      string
 }
 
 func NewClient(appID string) *Client {
 	return &Client{
-		URL:   DefaultURL,
-		AppID: appID,
+		URL:        DefaultURL,
+		OneCallURL: DefaultOneCallURL,
+		AppID:      appID,
 	}
 }
 
 type ErrorResponse struct {
-	Cod     int    `json:"cod"`
+	Cod     Code   `json:"cod"`
 	Message string `json:"message"`
 }
 
@@ -31,6 +40,20 @@ func (e *ErrorResponse) Error() string {
 	return fmt.Sprintf("openweather: cod=%d message=%s", e.Cod, e.Message)
 }
 
+func (e *ErrorResponse) APIErrorCode() string {
+	return strconv.Itoa(int(e.Cod))
+}
+
+// Code is response code that OpenWeather sends as number on success,
+// but as string on some errors, like "404" when city is not found.
+type Code int
+
+func (c *Code) UnmarshalJSON(data []byte) error {
+	val, err := strconv.Atoi(string(bytes.Trim(data, `"`)))
+	*c = Code(val)
+	return err
+}
+
 func (e *ErrorResponse) OK() bool {
 	return e.Message == ""
 }
@@ -58,14 +81,17 @@ type CurrentWeatherDataResponse struct {
 	} `json:"main"`
 	Visibility float64 `json:"visibility"`
 	Wind       struct {
-		Speed float64 `json:"speed"`
-		Deg   float64 `json:"deg"`
+		Speed float64  `json:"speed"`
+		Deg   float64  `json:"deg"`
+		Gust  *float64 `json:"gust"`
 	} `json:"wind"`
 	Clouds struct {
 		All float64 `json:"all"`
 	} `json:"clouds"`
-	Dt  int `json:"dt"`
-	Sys struct {
+	Rain Precipitation `json:"rain"`
+	Snow Precipitation `json:"snow"`
+	Dt   int           `json:"dt"`
+	Sys  struct {
 		Type    int     `json:"type"`
 		ID      int     `json:"id"`
 		Country string  `json:"country"`
@@ -77,10 +103,58 @@ type CurrentWeatherDataResponse struct {
 	Name     string `json:"name"`
 }
 
-func (c *Client) CurrentWeatherData(lat, lon float64) (*CurrentWeatherDataResponse, error) {
+// Precipitation volume in mm, fields are nil when they are missing in response.
+type Precipitation struct {
+	OneHour    *float64 `json:"1h"`
+	ThreeHours *float64 `json:"3h"`
+}
+
+const (
+	UnitsStandard = "standard"
+	UnitsMetric   = "metric"
+	UnitsImperial = "imperial"
+)
+
+// Location identifies location of requested data and how it is presented.
+// Location is looked up by the first identifier that is set: CityID, Q, Zip, and by Lat with Lon otherwise.
+type Location struct {
+	Lat    float64
+	Lon    float64
+	CityID int
+	Q      string
+	Zip    string
+	Units  string
+	Lang   string
+}
+
+func (l *Location) Query() url.Values {
 	query := url.Values{}
-	query.Set("lat", fmt.Sprint(lat))
-	query.Set("lon", fmt.Sprint(lon))
+	switch {
+	case l.CityID != 0:
+		query.Set("id", strconv.Itoa(l.CityID))
+	case l.Q != "":
+		query.Set("q", l.Q)
+	case l.Zip != "":
+		query.Set("zip", l.Zip)
+	default:
+		query.Set("lat", fmt.Sprint(l.Lat))
+		query.Set("lon", fmt.Sprint(l.Lon))
+	}
+	if l.Units != "" {
+		query.Set("units", l.Units)
+	}
+	if l.Lang != "" {
+		query.Set("lang", l.Lang)
+	}
+	return query
+}
+
+func (c *Client) CurrentWeatherData(lat, lon float64) (*CurrentWeatherDataResponse, error) {
+	return c.CurrentWeatherDataAt(&Location{Lat: lat, Lon: lon})
+}
+
+func (c *Client) CurrentWeatherDataAt(location *Location) (*CurrentWeatherDataResponse, error) {
+	query := location.Query()
 
 	var res CurrentWeatherDataResponse
 	if err := c.Request("/weather", query, &res); err != nil {
@@ -94,15 +168,251 @@ func (c *Client) CurrentWeatherData(lat, lon float64) (*CurrentWeatherDataRespon
 	return &res, nil
 }
 
+// OneCallResponse contains current weather, hourly forecast for 48 hours, daily forecast for 8 days and weather alerts.
+// First hourly entry is the current hour and first daily entry is today.
+type OneCallResponse struct {
+	ErrorResponse
+	Lat            float64         `json:"lat"`
+	Lon            float64         `json:"lon"`
+	Timezone       string          `json:"timezone"`
+	TimezoneOffset int             `json:"timezone_offset"`
+	Current        OneCallCurrent  `json:"current"`
+	Hourly         []OneCallHourly `json:"hourly"`
+	Daily          []OneCallDaily  `json:"daily"`
+	Alerts         []OneCallAlert  `json:"alerts"`
+}
+
+type OneCallCurrent struct {
+	Dt         int           `json:"dt"`
+	Sunrise    float64       `json:"sunrise"`
+	Sunset     float64       `json:"sunset"`
+	Temp       float64       `json:"temp"`
+	FeelsLike  float64       `json:"feels_like"`
+	Pressure   float64       `json:"pressure"`
+	Humidity   float64       `json:"humidity"`
+	DewPoint   float64       `json:"dew_point"`
+	UVI        float64       `json:"uvi"`
+	Clouds     float64       `json:"clouds"`
+	Visibility float64       `json:"visibility"`
+	WindSpeed  float64       `json:"wind_speed"`
+	WindDeg    float64       `json:"wind_deg"`
+	WindGust   *float64      `json:"wind_gust"`
+	Rain       Precipitation `json:"rain"`
+	Snow       Precipitation `json:"snow"`
+}
+
+type OneCallHourly struct {
+	Dt         int      `json:"dt"`
+	Temp       float64  `json:"temp"`
+	FeelsLike  float64  `json:"feels_like"`
+	Pressure   float64  `json:"pressure"`
+	Humidity   float64  `json:"humidity"`
+	DewPoint   float64  `json:"dew_point"`
+	UVI        float64  `json:"uvi"`
+	Clouds     float64  `json:"clouds"`
+	Visibility float64  `json:"visibility"`
+	WindSpeed  float64  `json:"wind_speed"`
+	WindDeg    float64  `json:"wind_deg"`
+	WindGust   *float64 `json:"wind_gust"`
+	// Pop is probability of precipitation from 0 to 1.
+	Pop  float64       `json:"pop"`
+	Rain Precipitation `json:"rain"`
+	Snow Precipitation `json:"snow"`
+}
+
+type OneCallDaily struct {
+	Dt      int     `json:"dt"`
+	Sunrise float64 `json:"sunrise"`
+	Sunset  float64 `json:"sunset"`
+	Temp    struct {
+		Day   float64 `json:"day"`
+		Min   float64 `json:"min"`
+		Max   float64 `json:"max"`
+		Night float64 `json:"night"`
+		Eve   float64 `json:"eve"`
+		Morn  float64 `json:"morn"`
+	} `json:"temp"`
+	FeelsLike struct {
+		Day   float64 `json:"day"`
+		Night float64 `json:"night"`
+		Eve   float64 `json:"eve"`
+		Morn  float64 `json:"morn"`
+	} `json:"feels_like"`
+	Pressure  float64  `json:"pressure"`
+	Humidity  float64  `json:"humidity"`
+	DewPoint  float64  `json:"dew_point"`
+	WindSpeed float64  `json:"wind_speed"`
+	WindDeg   float64  `json:"wind_deg"`
+	WindGust  *float64 `json:"wind_gust"`
+	Clouds    float64  `json:"clouds"`
+	UVI       float64  `json:"uvi"`
+	// Pop is probability of precipitation from 0 to 1.
+	Pop float64 `json:"pop"`
+	// Rain and Snow are volumes for the whole day in mm, unlike hourly ones they are not nested.
+	Rain *float64 `json:"rain"`
+	Snow *float64 `json:"snow"`
+}
+
+type OneCallAlert struct {
+	SenderName  string   `json:"sender_name"`
+	Event       string   `json:"event"`
+	Start       int      `json:"start"`
+	End         int      `json:"end"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+}
+
+// OneCall requests current weather and forecasts of location.
+// One Call API accepts only coordinates, other identifiers of location are ignored, config validation rejects them.
+// Minutely forecast is excluded, it is not exported.
+func (c *Client) OneCall(location *Location) (*OneCallResponse, error) {
+	query := url.Values{}
+	query.Set("lat", fmt.Sprint(location.Lat))
+	query.Set("lon", fmt.Sprint(location.Lon))
+	query.Set("exclude", "minutely")
+	if location.Units != "" {
+		query.Set("units", location.Units)
+	}
+	if location.Lang != "" {
+		query.Set("lang", location.Lang)
+	}
+
+	var res OneCallResponse
+	if err := c.get(c.OneCallURL+"/onecall", query, &res); err != nil {
+		return nil, fmt.Errorf("requesting /onecall: %w", err)
+	}
+
+	if !res.OK() {
+		return nil, &res.ErrorResponse
+	}
+
+	return &res, nil
+}
+
+type AirPollutionResponse struct {
+	ErrorResponse
+	Coord struct {
+		Lon float64 `json:"lon"`
+		Lat float64 `json:"lat"`
+	} `json:"coord"`
+	List []struct {
+		Dt   int `json:"dt"`
+		Main struct {
+			// AQI is Air Quality Index from 1 (good) to 5 (very poor).
+			AQI float64 `json:"aqi"`
+		} `json:"main"`
+		// Components are concentrations in μg/m3.
+		Components struct {
+			CO   float64 `json:"co"`
+			NO   float64 `json:"no"`
+			NO2  float64 `json:"no2"`
+			O3   float64 `json:"o3"`
+			SO2  float64 `json:"so2"`
+			PM25 float64 `json:"pm2_5"`
+			PM10 float64 `json:"pm10"`
+			NH3  float64 `json:"nh3"`
+		} `json:"components"`
+	} `json:"list"`
+}
+
+// AirPollution requests current air pollution of location.
+// Air Pollution API accepts only coordinates, other identifiers of location are ignored, config validation rejects them.
+func (c *Client) AirPollution(location *Location) (*AirPollutionResponse, error) {
+	query := url.Values{}
+	query.Set("lat", fmt.Sprint(location.Lat))
+	query.Set("lon", fmt.Sprint(location.Lon))
+
+	var res AirPollutionResponse
+	if err := c.Request("/air_pollution", query, &res); err != nil {
+		return nil, fmt.Errorf("requesting /air_pollution: %w", err)
+	}
+
+	if !res.OK() {
+		return nil, &res.ErrorResponse
+	}
+
+	return &res, nil
+}
+
+// ForecastResponse contains forecast for 5 days in 3-hour steps.
+type ForecastResponse struct {
+	Cod Code `json:"cod"`
+	// Message is number on success and error description otherwise, so ErrorResponse can not be embedded.
+	Message json.RawMessage `json:"message"`
+	List    []ForecastStep  `json:"list"`
+	City    struct {
+		ID      int    `json:"id"`
+		Name    string `json:"name"`
+		Country string `json:"country"`
+		Coord   struct {
+			Lon float64 `json:"lon"`
+			Lat float64 `json:"lat"`
+		} `json:"coord"`
+		Timezone int `json:"timezone"`
+	} `json:"city"`
+}
+
+type ForecastStep struct {
+	Dt   int `json:"dt"`
+	Main struct {
+		Temp      float64 `json:"temp"`
+		FeelsLike float64 `json:"feels_like"`
+		TempMin   float64 `json:"temp_min"`
+		TempMax   float64 `json:"temp_max"`
+		Pressure  float64 `json:"pressure"`
+		Humidity  float64 `json:"humidity"`
+	} `json:"main"`
+	Clouds struct {
+		All float64 `json:"all"`
+	} `json:"clouds"`
+	Wind struct {
+		Speed float64  `json:"speed"`
+		Deg   float64  `json:"deg"`
+		Gust  *float64 `json:"gust"`
+	} `json:"wind"`
+	Visibility *float64 `json:"visibility"`
+	// Pop is probability of precipitation from 0 to 1.
+	Pop  float64       `json:"pop"`
+	Rain Precipitation `json:"rain"`
+	Snow Precipitation `json:"snow"`
+}
+
+func (c *Client) Forecast(location *Location) (*ForecastResponse, error) {
+	query := location.Query()
+
+	var res ForecastResponse
+	if err := c.Request("/forecast", query, &res); err != nil {
+		return nil, fmt.Errorf("requesting /forecast: %w", err)
+	}
+
+	if res.Cod != http.StatusOK {
+		return nil, &ErrorResponse{
+			Cod:     res.Cod,
+			Message: string(bytes.Trim(res.Message, `"`)),
+		}
+	}
+
+	return &res, nil
+}
+
 func (c *Client) Request(endpoint string, query url.Values, dest interface{}) error {
+	return c.get(c.URL+endpoint, query, dest)
+}
+
+func (c *Client) get(endpointURL string, query url.Values, dest interface{}) error {
 	if query == nil {
 		query = url.Values{}
 	}
+	// URL in errors has app ID left out.
+	redactedURL := endpointURL + "?" + query.Encode()
 	query.Add("appid", c.AppID)
 
-	url := c.URL + endpoint + "?" + query.Encode()
-	res, err := http.Get(url)
+	res, err := http.Get(endpointURL + "?" + query.Encode())
 	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = redactedURL
+		}
 		return fmt.Errorf("sending HTTP GET request: %w", err)
 	}
 
@@ -112,7 +422,8 @@ func (c *Client) Request(endpoint string, query url.Values, dest interface{}) er
 	}
 
 	if err := json.Unmarshal(body, dest); err != nil {
-		return fmt.Errorf("unmarshaling response body: %w, content: %s", err, body)
+		// Content is left out, responses can echo credentials back.
+		return fmt.Errorf("unmarshaling response body of status %d (%d bytes): %w", res.StatusCode, len(body), err)
 	}
 
 	return nil
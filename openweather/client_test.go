@@ -61,3 +61,67 @@ func TestClient_Error(t *testing.T) {
 	require.Equal(t, &response, result.err)
 	require.Nil(t, result.cwd)
 }
+
+func TestLocation_Query(t *testing.T) {
+	tt := []struct {
+		location openweather.Location
+		query    string
+	}{
+		{
+			location: openweather.Location{Lat: 46.2389, Lon: 14.3556},
+			query:    "lat=46.2389&lon=14.3556",
+		},
+		{
+			location: openweather.Location{Lat: 46.2389, Lon: 14.3556, CityID: 3197378, Units: openweather.UnitsMetric},
+			query:    "id=3197378&units=metric",
+		},
+		{
+			location: openweather.Location{Q: "Kranj,SI", Lang: "sl"},
+			query:    "lang=sl&q=Kranj%2CSI",
+		},
+		{
+			location: openweather.Location{Zip: "4000,SI", Units: openweather.UnitsImperial},
+			query:    "units=imperial&zip=4000%2CSI",
+		},
+	}
+	for _, tc := range tt {
+		require.Equal(t, tc.query, tc.location.Query().Encode())
+	}
+}
+
+func TestClient_ErrorsWithoutAppID(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+
+	client := openweather.NewClient("my-app-id")
+	client.URL = server.URL
+
+	errChan := make(chan error)
+	go func() {
+		_, err := client.CurrentWeatherData(46.2389, 14.3556)
+		errChan <- err
+	}()
+
+	select {
+	case <-handler.Requests:
+		handler.Responses <- []byte(`<html>Invalid request appid=my-app-id</html>`)
+	case <-time.After(time.Second):
+		require.Fail(t, "request did not arrived")
+	}
+
+	var err error
+	select {
+	case err = <-errChan:
+	case <-time.After(time.Second):
+		require.Fail(t, "result did not arrived")
+	}
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "my-app-id")
+
+	// Error of failed request contains its URL.
+	server.Close()
+	_, err = client.CurrentWeatherData(46.2389, 14.3556)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "lat=46.2389")
+	require.NotContains(t, err.Error(), "my-app-id")
+}
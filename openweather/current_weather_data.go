@@ -3,25 +3,40 @@ package openweather
 import (
 	"context"
 	"log"
+	"sort"
 	"strconv"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/ulexxander/weather-prometheus-exporters/config"
+	"github.com/ulexxander/weather-prometheus-exporters/health"
+	"github.com/ulexxander/weather-prometheus-exporters/series"
 )
 
 type gauge struct {
 	subsystem string
 	name      string
 	value     func(res *CurrentWeatherDataResponse) float64
+	// optional is used instead of value for fields that may be missing in response.
+	// Gauge is not set when it returns nil, instead of setting misleading 0.
+	optional  func(res *CurrentWeatherDataResponse) *float64
 	collector *prometheus.GaugeVec
 }
 
 type CurrentWeatherData struct {
+	// Health reports outcome of updates, it is registered separately from CurrentWeatherData.
+	Health *health.Metrics
 	client *Client
 	config *config.OpenWeatherCurrentWeatherData
 	log    *log.Logger
+	series *series.Tracker
 	gauges []gauge
+	// extraLabels are names of user-defined labels of all configured locations.
+	extraLabels []string
+	// conditionInfo exports weather conditions as labels, there can be more than one at a time.
+	conditionInfo *prometheus.GaugeVec
+	// locationInfo exports location details that are not numeric measurements as labels.
+	locationInfo *prometheus.GaugeVec
 }
 
 func NewCurrentWeatherData(
@@ -30,7 +45,11 @@ func NewCurrentWeatherData(
 	log *log.Logger,
 ) *CurrentWeatherData {
 	const namespace = "open_weather"
-	labels := []string{"id", "name"}
+	labels := []string{"id", "name", "unit", "location"}
+	conditionLabels := []string{"condition_id", "main", "description", "icon"}
+	locationLabels := []string{"lat", "lon", "country", "timezone"}
+	extraLabels := extraLabelNames(config.Coords, labels, conditionLabels, locationLabels)
+	labels = append(labels, extraLabels...)
 
 	gauges := []gauge{
 		// Main gauges.
@@ -75,12 +94,59 @@ func NewCurrentWeatherData(
 			name:      "deg",
 			value:     func(res *CurrentWeatherDataResponse) float64 { return res.Wind.Deg },
 		},
+		{
+			subsystem: "wind",
+			name:      "gust",
+			optional:  func(res *CurrentWeatherDataResponse) *float64 { return res.Wind.Gust },
+		},
 		// Clouds gauges.
 		{
 			subsystem: "clouds",
 			name:      "all",
 			value:     func(res *CurrentWeatherDataResponse) float64 { return res.Clouds.All },
 		},
+		// Rain gauges.
+		{
+			subsystem: "rain",
+			name:      "1h",
+			optional:  func(res *CurrentWeatherDataResponse) *float64 { return res.Rain.OneHour },
+		},
+		{
+			subsystem: "rain",
+			name:      "3h",
+			optional:  func(res *CurrentWeatherDataResponse) *float64 { return res.Rain.ThreeHours },
+		},
+		// Snow gauges.
+		{
+			subsystem: "snow",
+			name:      "1h",
+			optional:  func(res *CurrentWeatherDataResponse) *float64 { return res.Snow.OneHour },
+		},
+		{
+			subsystem: "snow",
+			name:      "3h",
+			optional:  func(res *CurrentWeatherDataResponse) *float64 { return res.Snow.ThreeHours },
+		},
+		// Sunrise and sunset gauges.
+		{
+			subsystem: "sys",
+			name:      "sunrise_timestamp_seconds",
+			value:     func(res *CurrentWeatherDataResponse) float64 { return res.Sys.Sunrise },
+		},
+		{
+			subsystem: "sys",
+			name:      "sunset_timestamp_seconds",
+			value:     func(res *CurrentWeatherDataResponse) float64 { return res.Sys.Sunset },
+		},
+		{
+			name:  "visibility_meters",
+			value: func(res *CurrentWeatherDataResponse) float64 { return res.Visibility },
+		},
+		// Time of data calculation.
+		{
+			name:  "measurement_timestamp_seconds",
+			value: func(res *CurrentWeatherDataResponse) float64 { return float64(res.Dt) },
+		},
 	}
 
 	for i := range gauges {
@@ -92,11 +158,28 @@ func NewCurrentWeatherData(
 		}, labels)
 	}
 
+	conditionInfo := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "condition_info",
+		Help:      "Weather condition, value is always 1.",
+	}, append(labels[:len(labels):len(labels)], conditionLabels...))
+
+	locationInfo := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "location_info",
+		Help:      "Location details, timezone is shift from UTC in seconds, value is always 1.",
+	}, append(labels[:len(labels):len(labels)], locationLabels...))
+
 	return &CurrentWeatherData{
-		client: client,
-		config: config,
-		log:    log,
-		gauges: gauges,
+		Health:        health.NewMetrics(namespace, "current_weather_data"),
+		extraLabels:   extraLabels,
+		client:        client,
+		config:        config,
+		log:           log,
+		series:        series.NewTracker(),
+		gauges:        gauges,
+		conditionInfo: conditionInfo,
+		locationInfo:  locationInfo,
 	}
 }
 
@@ -104,12 +187,12 @@ func (cwd *CurrentWeatherData) Describe(d chan<- *prometheus.Desc) {
 	for _, g := range cwd.gauges {
 		g.collector.Describe(d)
 	}
+	cwd.conditionInfo.Describe(d)
+	cwd.locationInfo.Describe(d)
 }
 
 func (cwd *CurrentWeatherData) Collect(m chan<- prometheus.Metric) {
-	for _, g := range cwd.gauges {
-		g.collector.Collect(m)
-	}
+	cwd.series.Collect(m)
 }
 
 func (cwd *CurrentWeatherData) Run(ctx context.Context) {
@@ -127,17 +210,19 @@ func (cwd *CurrentWeatherData) Run(ctx context.Context) {
 
 func (cwd *CurrentWeatherData) Update() {
 	type result struct {
-		res *CurrentWeatherDataResponse
-		err error
+		coords config.Coordinates
+		res    *CurrentWeatherDataResponse
+		err    error
 	}
 
 	results := make(chan result, len(cwd.config.Coords))
 	start := time.Now()
+	var errs []error
 
 	for _, coords := range cwd.config.Coords {
 		go func(coords config.Coordinates) {
-			res, err := cwd.client.CurrentWeatherData(coords.Lat, coords.Lon)
-			results <- result{res, err}
+			res, err := cwd.client.CurrentWeatherDataAt(location(&coords))
+			results <- result{coords, res, err}
 		}(coords)
 	}
 
@@ -145,22 +230,119 @@ func (cwd *CurrentWeatherData) Update() {
 		result := <-results
 		if result.err != nil {
 			cwd.log.Println("Error fetching Current Weather Data:", result.err)
+			errs = append(errs, result.err)
 			continue
 		}
 
 		labels := prometheus.Labels{
-			"id":   strconv.Itoa(result.res.ID),
-			"name": result.res.Name,
+			"id":       strconv.Itoa(result.res.ID),
+			"name":     result.res.Name,
+			"unit":     unit(&result.coords),
+			"location": result.coords.Name,
+		}
+		for _, name := range cwd.extraLabels {
+			labels[name] = result.coords.Labels[name]
 		}
 
+		var measuredAt time.Time
+		if cwd.config.SampleTimestamps && result.res.Dt != 0 {
+			measuredAt = time.Unix(int64(result.res.Dt), 0)
+		}
 		for _, g := range cwd.gauges {
+			if g.optional != nil {
+				if val := g.optional(result.res); val != nil {
+					cwd.series.SetWithTimestamp(g.collector, labels, *val, measuredAt)
+				}
+				continue
+			}
 			val := g.value(result.res)
-			g.collector.With(labels).Set(val)
+			cwd.series.SetWithTimestamp(g.collector, labels, val, measuredAt)
 		}
 
+		for _, weather := range result.res.Weather {
+			conditionLabels := withLabels(labels, prometheus.Labels{
+				"condition_id": strconv.Itoa(weather.ID),
+				"main":         weather.Main,
+				"description":  weather.Description,
+				"icon":         weather.Icon,
+			})
+			cwd.series.SetWithTimestamp(cwd.conditionInfo, conditionLabels, 1, measuredAt)
+		}
+
+		locationLabels := withLabels(labels, prometheus.Labels{
+			"lat":      strconv.FormatFloat(result.res.Coord.Lat, 'f', -1, 64),
+			"lon":      strconv.FormatFloat(result.res.Coord.Lon, 'f', -1, 64),
+			"country":  result.res.Sys.Country,
+			"timezone": strconv.Itoa(result.res.Timezone),
+		})
+		cwd.series.SetWithTimestamp(cwd.locationInfo, locationLabels, 1, measuredAt)
+		// Grace period is for locations missing from responses,
+		// series of this one that were not set, like ended conditions or missing rain, are deleted right away.
+		cwd.series.DeleteStaleOf(labels, start)
+
 		cwd.log.Printf("Processed Current Weather Data of %s (%d)", result.res.Name, result.res.ID)
 	}
 
-	duration := time.Since(start)
-	cwd.log.Println("Updated Current Weather Data successfully, took", duration)
+	cwd.Health.Finish(health.Update{
+		Name:    "Current Weather Data",
+		Start:   start,
+		Targets: len(cwd.config.Coords),
+		Errs:    errs,
+	}, cwd.series, time.Duration(cwd.config.StaleGracePeriod), cwd.log)
+}
+
+func location(coords *config.Coordinates) *Location {
+	return &Location{
+		Lat:    coords.Lat,
+		Lon:    coords.Lon,
+		CityID: coords.CityID,
+		Q:      coords.Q,
+		Zip:    coords.Zip,
+		Units:  coords.Units,
+		Lang:   coords.Lang,
+	}
+}
+
+// unit returns units of measurements at given coordinates, they are standard if not specified.
+func unit(coords *config.Coordinates) string {
+	if coords.Units == "" {
+		return UnitsStandard
+	}
+	return coords.Units
+}
+
+// extraLabelNames returns sorted union of user-defined label names of all locations.
+// Names that are already used by exporter itself are left out, so they can not override them.
+func extraLabelNames(coords []config.Coordinates, reserved ...[]string) []string {
+	isReserved := map[string]bool{}
+	for _, names := range reserved {
+		for _, name := range names {
+			isReserved[name] = true
+		}
+	}
+	seen := map[string]bool{}
+	var names []string
+	for _, c := range coords {
+		for name := range c.Labels {
+			if isReserved[name] || seen[name] {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// withLabels returns union of labels, extra ones take precedence.
+func withLabels(labels, extra prometheus.Labels) prometheus.Labels {
+	res := make(prometheus.Labels, len(labels)+len(extra))
+	for k, v := range labels {
+		res[k] = v
+	}
+	for k, v := range extra {
+		res[k] = v
+	}
+	return res
 }
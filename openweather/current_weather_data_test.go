@@ -3,12 +3,14 @@ package openweather_test
 import (
 	"log"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	dto "github.com/prometheus/client_model/go"
 
 	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 	"github.com/ulexxander/weather-prometheus-exporters/config"
 	"github.com/ulexxander/weather-prometheus-exporters/openweather"
@@ -26,8 +28,10 @@ func TestCurrentWeatherData(t *testing.T) {
 	config := &config.OpenWeatherCurrentWeatherData{
 		Coords: []config.Coordinates{
 			{
-				Lat: 46.2389,
-				Lon: 14.3556,
+				Lat:    46.2389,
+				Lon:    14.3556,
+				Name:   "Office",
+				Labels: map[string]string{"site": "hq"},
 			},
 		},
 	}
@@ -67,7 +71,10 @@ func TestCurrentWeatherData(t *testing.T) {
 				{
 					Label: []*dto.LabelPair{
 						{Name: sptr("id"), Value: sptr("3197378")},
+						{Name: sptr("location"), Value: sptr("Office")},
 						{Name: sptr("name"), Value: sptr("Kranj")},
+						{Name: sptr("site"), Value: sptr("hq")},
+						{Name: sptr("unit"), Value: sptr("standard")},
 					},
 					Gauge: &dto.Gauge{
 						Value: fptr(value),
@@ -77,21 +84,285 @@ func TestCurrentWeatherData(t *testing.T) {
 		}
 	}
 
+	infoMetric := func(name, help string, labels ...*dto.LabelPair) *dto.MetricFamily {
+		family := metric(name, 1)
+		family.Help = sptr(help)
+		family.Metric[0].Label = labels
+		return family
+	}
+
 	expectedMetrics := []*dto.MetricFamily{
 		metric("clouds_all", 75),
+		infoMetric("condition_info", "Weather condition, value is always 1.",
+			&dto.LabelPair{Name: sptr("condition_id"), Value: sptr("803")},
+			&dto.LabelPair{Name: sptr("description"), Value: sptr("broken clouds")},
+			&dto.LabelPair{Name: sptr("icon"), Value: sptr("04d")},
+			&dto.LabelPair{Name: sptr("id"), Value: sptr("3197378")},
+			&dto.LabelPair{Name: sptr("location"), Value: sptr("Office")},
+			&dto.LabelPair{Name: sptr("main"), Value: sptr("Clouds")},
+			&dto.LabelPair{Name: sptr("name"), Value: sptr("Kranj")},
+			&dto.LabelPair{Name: sptr("site"), Value: sptr("hq")},
+			&dto.LabelPair{Name: sptr("unit"), Value: sptr("standard")},
+		),
+		infoMetric("location_info", "Location details, timezone is shift from UTC in seconds, value is always 1.",
+			&dto.LabelPair{Name: sptr("country"), Value: sptr("SI")},
+			&dto.LabelPair{Name: sptr("id"), Value: sptr("3197378")},
+			&dto.LabelPair{Name: sptr("lat"), Value: sptr("46.2389")},
+			&dto.LabelPair{Name: sptr("location"), Value: sptr("Office")},
+			&dto.LabelPair{Name: sptr("lon"), Value: sptr("14.3556")},
+			&dto.LabelPair{Name: sptr("name"), Value: sptr("Kranj")},
+			&dto.LabelPair{Name: sptr("site"), Value: sptr("hq")},
+			&dto.LabelPair{Name: sptr("timezone"), Value: sptr("7200")},
+			&dto.LabelPair{Name: sptr("unit"), Value: sptr("standard")},
+		),
 		metric("main_feels_like", 287.29),
 		metric("main_humidity", 72),
 		metric("main_pressure", 1015),
 		metric("main_temp", 287.88),
 		metric("main_temp_max", 289.04),
 		metric("main_temp_min", 284.16),
+		metric("measurement_timestamp_seconds", 1651487420),
+		metric("rain_1h", 0.25),
+		metric("sys_sunrise_timestamp_seconds", 1651463259),
+		metric("sys_sunset_timestamp_seconds", 1651515081),
+		metric("visibility_meters", 10000),
 		metric("wind_deg", 290),
+		metric("wind_gust", 7.2),
 		metric("wind_speed", 3.6),
 	}
 
 	require.Equal(t, expectedMetrics, gatheredMetrics)
 }
 
+func TestCurrentWeatherData_StaleSeries(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := openweather.NewClient("my-app-id")
+	client.URL = server.URL
+
+	config := &config.OpenWeatherCurrentWeatherData{
+		Coords: []config.Coordinates{
+			{
+				Lat: 46.2389,
+				Lon: 14.3556,
+			},
+		},
+	}
+	cwd := openweather.NewCurrentWeatherData(client, config, log.Default())
+
+	reg := prometheus.NewRegistry()
+	err := reg.Register(cwd)
+	require.NoError(t, err)
+
+	update := func(response interface{}) {
+		updated := make(chan struct{})
+		go func() {
+			cwd.Update()
+			updated <- struct{}{}
+		}()
+
+		select {
+		case <-handler.Requests:
+			handler.Responses <- response
+		case <-time.After(time.Second):
+			require.Fail(t, "request did not arrived")
+		}
+
+		<-updated
+	}
+
+	update([]byte(response))
+
+	// Coordinates were changed and now resolve to another city.
+	update([]byte(strings.NewReplacer(`"id": 3197378`, `"id": 3196359`, `"Kranj"`, `"Ljubljana"`).Replace(response)))
+
+	gatheredMetrics, err := reg.Gather()
+	require.NoError(t, err)
+
+	for _, family := range gatheredMetrics {
+		require.Len(t, family.Metric, 1, family.GetName())
+		labels := map[string]string{}
+		for _, label := range family.Metric[0].Label {
+			labels[label.GetName()] = label.GetValue()
+		}
+		require.Equal(t, "3196359", labels["id"], family.GetName())
+		require.Equal(t, "Ljubljana", labels["name"], family.GetName())
+	}
+}
+
+func TestCurrentWeatherData_ConditionChange(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := openweather.NewClient("my-app-id")
+	client.URL = server.URL
+
+	config := &config.OpenWeatherCurrentWeatherData{
+		Coords: []config.Coordinates{
+			{
+				Lat: 46.2389,
+				Lon: 14.3556,
+			},
+		},
+		StaleGracePeriod: config.Duration(10 * time.Minute),
+	}
+	cwd := openweather.NewCurrentWeatherData(client, config, log.Default())
+
+	reg := prometheus.NewRegistry()
+	err := reg.Register(cwd)
+	require.NoError(t, err)
+
+	update := func(response interface{}) {
+		updated := make(chan struct{})
+		go func() {
+			cwd.Update()
+			updated <- struct{}{}
+		}()
+
+		select {
+		case <-handler.Requests:
+			handler.Responses <- response
+		case <-time.After(time.Second):
+			require.Fail(t, "request did not arrived")
+		}
+
+		<-updated
+	}
+
+	update([]byte(response))
+
+	// Location still responds, so ended condition is not kept for grace period.
+	update([]byte(strings.NewReplacer(
+		`"id": 803`, `"id": 800`,
+		`"main": "Clouds"`, `"main": "Clear"`,
+		`"description": "broken clouds"`, `"description": "clear sky"`,
+		`"icon": "04d"`, `"icon": "01d"`,
+	).Replace(response)))
+
+	gatheredMetrics, err := reg.Gather()
+	require.NoError(t, err)
+
+	var conditions []string
+	for _, family := range gatheredMetrics {
+		if family.GetName() != "open_weather_condition_info" {
+			continue
+		}
+		for _, metric := range family.Metric {
+			for _, label := range metric.Label {
+				if label.GetName() == "main" {
+					conditions = append(conditions, label.GetValue())
+				}
+			}
+		}
+	}
+	require.Equal(t, []string{"Clear"}, conditions)
+}
+
+func TestCurrentWeatherData_MissingOptionalFields(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := openweather.NewClient("my-app-id")
+	client.URL = server.URL
+
+	config := &config.OpenWeatherCurrentWeatherData{
+		Coords: []config.Coordinates{
+			{
+				Lat: 46.2389,
+				Lon: 14.3556,
+			},
+		},
+		StaleGracePeriod: config.Duration(10 * time.Minute),
+	}
+	cwd := openweather.NewCurrentWeatherData(client, config, log.Default())
+
+	reg := prometheus.NewRegistry()
+	err := reg.Register(cwd)
+	require.NoError(t, err)
+
+	update := func(response interface{}) {
+		updated := make(chan struct{})
+		go func() {
+			cwd.Update()
+			updated <- struct{}{}
+		}()
+
+		select {
+		case <-handler.Requests:
+			handler.Responses <- response
+		case <-time.After(time.Second):
+			require.Fail(t, "request did not arrived")
+		}
+
+		<-updated
+	}
+
+	update([]byte(response))
+	require.Equal(t, 1, promtestutil.CollectAndCount(cwd, "open_weather_rain_1h"))
+	require.Equal(t, 1, promtestutil.CollectAndCount(cwd, "open_weather_wind_gust"))
+
+	// It stopped raining and wind calmed down, so rain and gust are no longer in response.
+	update([]byte(strings.NewReplacer(
+		`"rain": { "1h": 0.25 },`, ``,
+		`, "gust": 7.2`, ``,
+	).Replace(response)))
+	require.Equal(t, 0, promtestutil.CollectAndCount(cwd, "open_weather_rain_1h"))
+	require.Equal(t, 0, promtestutil.CollectAndCount(cwd, "open_weather_wind_gust"))
+	require.Equal(t, 1, promtestutil.CollectAndCount(cwd, "open_weather_main_temp"))
+}
+
+func TestCurrentWeatherData_Error(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := openweather.NewClient("my-app-id")
+	client.URL = server.URL
+
+	config := &config.OpenWeatherCurrentWeatherData{
+		Coords: []config.Coordinates{
+			{
+				Lat: 46.2389,
+				Lon: 14.3556,
+			},
+		},
+	}
+	cwd := openweather.NewCurrentWeatherData(client, config, log.Default())
+
+	updated := make(chan struct{})
+	go func() {
+		cwd.Update()
+		updated <- struct{}{}
+	}()
+
+	select {
+	case <-handler.Requests:
+		handler.Responses <- []byte(`{"cod":"404","message":"city not found"}`)
+	case <-time.After(time.Second):
+		require.Fail(t, "request did not arrived")
+	}
+
+	<-updated
+
+	expected := `
+# HELP open_weather_current_weather_data_up Whether the last update succeeded.
+# TYPE open_weather_current_weather_data_up gauge
+open_weather_current_weather_data_up 0
+# HELP open_weather_current_weather_data_update_errors_total Errors occurred during updates by class and API error code.
+# TYPE open_weather_current_weather_data_update_errors_total counter
+open_weather_current_weather_data_update_errors_total{class="api",code="404"} 1
+`
+	err := promtestutil.CollectAndCompare(cwd.Health, strings.NewReader(expected),
+		"open_weather_current_weather_data_up",
+		"open_weather_current_weather_data_update_errors_total",
+	)
+	require.NoError(t, err)
+}
+
 const response = `{
   "coord": { "lon": 14.3556, "lat": 46.2389 },
   "weather": [
@@ -112,8 +383,9 @@ const response = `{
     "humidity": 72
   },
   "visibility": 10000,
-  "wind": { "speed": 3.6, "deg": 290 },
+  "wind": { "speed": 3.6, "deg": 290, "gust": 7.2 },
   "clouds": { "all": 75 },
+  "rain": { "1h": 0.25 },
   "dt": 1651487420,
   "sys": {
     "type": 1,
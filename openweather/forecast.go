@@ -0,0 +1,181 @@
+package openweather
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+	"github.com/ulexxander/weather-prometheus-exporters/health"
+	"github.com/ulexxander/weather-prometheus-exporters/series"
+)
+
+// forecastStepDuration is time between forecast steps.
+const forecastStepDuration = 3 * time.Hour
+
+type forecastGauge struct {
+	name      string
+	value     func(step *ForecastStep) float64
+	optional  func(step *ForecastStep) *float64
+	collector *prometheus.GaugeVec
+}
+
+// Forecast exports 5 day forecast in 3-hour steps.
+// Steps are labelled by offset, like "+3h" for the first step and "+120h" for the last one.
+type Forecast struct {
+	// Health reports outcome of updates, it is registered separately from Forecast.
+	Health *health.Metrics
+	client *Client
+	config *config.OpenWeatherForecast
+	log    *log.Logger
+	series *series.Tracker
+	// extraLabels are names of user-defined labels of all configured locations.
+	extraLabels []string
+	gauges      []forecastGauge
+}
+
+func NewForecast(
+	client *Client,
+	config *config.OpenWeatherForecast,
+	log *log.Logger,
+) *Forecast {
+	const namespace = "open_weather"
+	const subsystem = "forecast"
+	labels := []string{"id", "name", "unit", "location", "offset"}
+	extraLabels := extraLabelNames(config.Coords, labels)
+	labels = append(labels, extraLabels...)
+
+	gauges := []forecastGauge{
+		{name: "temp", value: func(s *ForecastStep) float64 { return s.Main.Temp }},
+		{name: "feels_like", value: func(s *ForecastStep) float64 { return s.Main.FeelsLike }},
+		{name: "temp_min", value: func(s *ForecastStep) float64 { return s.Main.TempMin }},
+		{name: "temp_max", value: func(s *ForecastStep) float64 { return s.Main.TempMax }},
+		{name: "pressure", value: func(s *ForecastStep) float64 { return s.Main.Pressure }},
+		{name: "humidity", value: func(s *ForecastStep) float64 { return s.Main.Humidity }},
+		{name: "wind_speed", value: func(s *ForecastStep) float64 { return s.Wind.Speed }},
+		{name: "wind_deg", value: func(s *ForecastStep) float64 { return s.Wind.Deg }},
+		{name: "wind_gust", optional: func(s *ForecastStep) *float64 { return s.Wind.Gust }},
+		{name: "clouds_all", value: func(s *ForecastStep) float64 { return s.Clouds.All }},
+		{name: "visibility_meters", optional: func(s *ForecastStep) *float64 { return s.Visibility }},
+		{name: "pop", value: func(s *ForecastStep) float64 { return s.Pop }},
+		{name: "rain_3h", optional: func(s *ForecastStep) *float64 { return s.Rain.ThreeHours }},
+		{name: "snow_3h", optional: func(s *ForecastStep) *float64 { return s.Snow.ThreeHours }},
+		{name: "forecast_timestamp_seconds", value: func(s *ForecastStep) float64 { return float64(s.Dt) }},
+	}
+
+	for i := range gauges {
+		g := &gauges[i]
+		g.collector = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      g.name,
+		}, labels)
+	}
+
+	return &Forecast{
+		Health:      health.NewMetrics(namespace, subsystem),
+		client:      client,
+		config:      config,
+		log:         log,
+		series:      series.NewTracker(),
+		extraLabels: extraLabels,
+		gauges:      gauges,
+	}
+}
+
+func (f *Forecast) Describe(d chan<- *prometheus.Desc) {
+	for _, g := range f.gauges {
+		g.collector.Describe(d)
+	}
+}
+
+func (f *Forecast) Collect(m chan<- prometheus.Metric) {
+	f.series.Collect(m)
+}
+
+func (f *Forecast) Run(ctx context.Context) {
+	f.Update()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(f.config.Interval)):
+			f.Update()
+		}
+	}
+}
+
+func (f *Forecast) Update() {
+	type result struct {
+		coords config.Coordinates
+		res    *ForecastResponse
+		err    error
+	}
+
+	results := make(chan result, len(f.config.Coords))
+	start := time.Now()
+	var errs []error
+
+	for _, coords := range f.config.Coords {
+		go func(coords config.Coordinates) {
+			res, err := f.client.Forecast(location(&coords))
+			results <- result{coords, res, err}
+		}(coords)
+	}
+
+	for i := 0; i < len(f.config.Coords); i++ {
+		result := <-results
+		if result.err != nil {
+			f.log.Println("Error fetching Forecast:", result.err)
+			errs = append(errs, result.err)
+			continue
+		}
+
+		labels := prometheus.Labels{
+			"id":       strconv.Itoa(result.res.City.ID),
+			"name":     result.res.City.Name,
+			"unit":     unit(&result.coords),
+			"location": result.coords.Name,
+		}
+		for _, name := range f.extraLabels {
+			labels[name] = result.coords.Labels[name]
+		}
+
+		for i := range result.res.List {
+			if f.config.Steps != 0 && i >= f.config.Steps {
+				break
+			}
+			offset := time.Duration(i+1) * forecastStepDuration
+			stepLabels := withLabels(labels, prometheus.Labels{
+				"offset": fmt.Sprintf("+%dh", int(offset.Hours())),
+			})
+			step := &result.res.List[i]
+			for _, g := range f.gauges {
+				if g.optional != nil {
+					if val := g.optional(step); val != nil {
+						f.series.Set(g.collector, stepLabels, *val)
+					}
+					continue
+				}
+				f.series.Set(g.collector, stepLabels, g.value(step))
+			}
+		}
+
+		// Grace period is for locations missing from responses,
+		// series of this one that were not set, like missing rain or dropped steps, are deleted right away.
+		f.series.DeleteStaleOf(labels, start)
+
+		f.log.Printf("Processed Forecast of %s (%d)", result.res.City.Name, result.res.City.ID)
+	}
+
+	f.Health.Finish(health.Update{
+		Name:    "Forecast",
+		Start:   start,
+		Targets: len(f.config.Coords),
+		Errs:    errs,
+	}, f.series, time.Duration(f.config.StaleGracePeriod), f.log)
+}
@@ -0,0 +1,231 @@
+package openweather_test
+
+import (
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+	"github.com/ulexxander/weather-prometheus-exporters/openweather"
+	"github.com/ulexxander/weather-prometheus-exporters/testutil"
+)
+
+func TestForecast(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := openweather.NewClient("my-app-id")
+	client.URL = server.URL
+
+	config := &config.OpenWeatherForecast{
+		Coords: []config.Coordinates{
+			{
+				CityID: 3197378,
+				Units:  openweather.UnitsMetric,
+				Name:   "Office",
+			},
+		},
+	}
+	forecast := openweather.NewForecast(client, config, log.Default())
+
+	reg := prometheus.NewRegistry()
+	err := reg.Register(forecast)
+	require.NoError(t, err)
+
+	updated := make(chan struct{})
+	go func() {
+		forecast.Update()
+		updated <- struct{}{}
+	}()
+
+	var r *http.Request
+	select {
+	case r = <-handler.Requests:
+		handler.Responses <- []byte(forecastResponse)
+	case <-time.After(time.Second):
+		require.Fail(t, "request did not arrived")
+	}
+
+	<-updated
+
+	require.Equal(t, "/forecast", r.URL.Path)
+	require.Equal(t, "3197378", r.URL.Query().Get("id"))
+	require.Equal(t, "metric", r.URL.Query().Get("units"))
+
+	gatheredMetrics, err := reg.Gather()
+	require.NoError(t, err)
+
+	// Values by metric name and offset label.
+	values := map[string]map[string]float64{}
+	for _, family := range gatheredMetrics {
+		values[family.GetName()] = map[string]float64{}
+		for _, metric := range family.Metric {
+			labels := map[string]string{}
+			for _, label := range metric.Label {
+				labels[label.GetName()] = label.GetValue()
+			}
+			require.Equal(t, "3197378", labels["id"], family.GetName())
+			require.Equal(t, "Kranj", labels["name"], family.GetName())
+			require.Equal(t, "metric", labels["unit"], family.GetName())
+			require.Equal(t, "Office", labels["location"], family.GetName())
+			values[family.GetName()][labels["offset"]] = metric.GetGauge().GetValue()
+		}
+	}
+
+	require.Equal(t, map[string]float64{"+3h": 14.2, "+6h": 11.8}, values["open_weather_forecast_temp"])
+	require.Equal(t, map[string]float64{"+3h": 68, "+6h": 81}, values["open_weather_forecast_humidity"])
+	require.Equal(t, map[string]float64{"+3h": 1014, "+6h": 1012}, values["open_weather_forecast_pressure"])
+	require.Equal(t, map[string]float64{"+3h": 0.12, "+6h": 0.74}, values["open_weather_forecast_pop"])
+	require.Equal(t, map[string]float64{"+6h": 1.37}, values["open_weather_forecast_rain_3h"])
+	require.Equal(t, map[string]float64{"+3h": 1651492800, "+6h": 1651503600}, values["open_weather_forecast_forecast_timestamp_seconds"])
+	require.NotContains(t, values, "open_weather_forecast_snow_3h")
+}
+
+func TestForecast_MissingOptionalFields(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := openweather.NewClient("my-app-id")
+	client.URL = server.URL
+
+	config := &config.OpenWeatherForecast{
+		Coords: []config.Coordinates{
+			{
+				CityID: 3197378,
+			},
+		},
+		StaleGracePeriod: config.Duration(10 * time.Minute),
+	}
+	forecast := openweather.NewForecast(client, config, log.Default())
+
+	reg := prometheus.NewRegistry()
+	err := reg.Register(forecast)
+	require.NoError(t, err)
+
+	update := func(response interface{}) {
+		updated := make(chan struct{})
+		go func() {
+			forecast.Update()
+			updated <- struct{}{}
+		}()
+
+		select {
+		case <-handler.Requests:
+			handler.Responses <- response
+		case <-time.After(time.Second):
+			require.Fail(t, "request did not arrived")
+		}
+
+		<-updated
+	}
+
+	update([]byte(forecastResponse))
+	require.Equal(t, 1, promtestutil.CollectAndCount(forecast, "open_weather_forecast_rain_3h"))
+
+	// Rain is no longer forecast.
+	update([]byte(strings.Replace(forecastResponse, `"rain": { "3h": 1.37 },`, ``, 1)))
+	require.Equal(t, 0, promtestutil.CollectAndCount(forecast, "open_weather_forecast_rain_3h"))
+	require.Equal(t, 2, promtestutil.CollectAndCount(forecast, "open_weather_forecast_temp"))
+}
+
+func TestForecast_Error(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := openweather.NewClient("my-app-id")
+	client.URL = server.URL
+
+	type requestResult struct {
+		forecast *openweather.ForecastResponse
+		err      error
+	}
+	resultChan := make(chan requestResult)
+	go func() {
+		forecast, err := client.Forecast(&openweather.Location{Q: "Nowhere"})
+		resultChan <- requestResult{forecast, err}
+	}()
+
+	select {
+	case <-handler.Requests:
+		handler.Responses <- []byte(`{"cod":"404","message":"city not found"}`)
+	case <-time.After(time.Second):
+		require.Fail(t, "request did not arrived")
+	}
+
+	var result requestResult
+	select {
+	case result = <-resultChan:
+	case <-time.After(time.Second):
+		require.Fail(t, "result did not arrived")
+	}
+
+	require.Equal(t, &openweather.ErrorResponse{Cod: 404, Message: "city not found"}, result.err)
+	require.Nil(t, result.forecast)
+}
+
+const forecastResponse = `{
+  "cod": "200",
+  "message": 0,
+  "cnt": 2,
+  "list": [
+    {
+      "dt": 1651492800,
+      "main": {
+        "temp": 14.2,
+        "feels_like": 13.5,
+        "temp_min": 13.9,
+        "temp_max": 14.2,
+        "pressure": 1014,
+        "humidity": 68
+      },
+      "weather": [
+        { "id": 803, "main": "Clouds", "description": "broken clouds", "icon": "04d" }
+      ],
+      "clouds": { "all": 75 },
+      "wind": { "speed": 3.1, "deg": 285, "gust": 5.6 },
+      "visibility": 10000,
+      "pop": 0.12,
+      "sys": { "pod": "d" },
+      "dt_txt": "2022-05-02 12:00:00"
+    },
+    {
+      "dt": 1651503600,
+      "main": {
+        "temp": 11.8,
+        "feels_like": 11.3,
+        "temp_min": 11.8,
+        "temp_max": 11.8,
+        "pressure": 1012,
+        "humidity": 81
+      },
+      "weather": [
+        { "id": 500, "main": "Rain", "description": "light rain", "icon": "10n" }
+      ],
+      "clouds": { "all": 100 },
+      "wind": { "speed": 2.4, "deg": 260, "gust": 4.9 },
+      "visibility": 10000,
+      "pop": 0.74,
+      "rain": { "3h": 1.37 },
+      "sys": { "pod": "n" },
+      "dt_txt": "2022-05-02 15:00:00"
+    }
+  ],
+  "city": {
+    "id": 3197378,
+    "name": "Kranj",
+    "coord": { "lat": 46.2389, "lon": 14.3556 },
+    "country": "SI",
+    "timezone": 7200,
+    "sunrise": 1651463259,
+    "sunset": 1651515081
+  }
+}`
@@ -0,0 +1,310 @@
+package openweather
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+	"github.com/ulexxander/weather-prometheus-exporters/health"
+	"github.com/ulexxander/weather-prometheus-exporters/series"
+)
+
+type oneCallCurrentGauge struct {
+	name      string
+	value     func(current *OneCallCurrent) float64
+	optional  func(current *OneCallCurrent) *float64
+	collector *prometheus.GaugeVec
+}
+
+type oneCallHourlyGauge struct {
+	name      string
+	value     func(hourly *OneCallHourly) float64
+	optional  func(hourly *OneCallHourly) *float64
+	collector *prometheus.GaugeVec
+}
+
+type oneCallDailyGauge struct {
+	name      string
+	value     func(daily *OneCallDaily) float64
+	optional  func(daily *OneCallDaily) *float64
+	collector *prometheus.GaugeVec
+}
+
+// OneCall exports current weather and forecasts from One Call API 3.0.
+// Forecasts are labelled by horizon, like "+1h" for the next hour and "+1d" for tomorrow.
+type OneCall struct {
+	// Health reports outcome of updates, it is registered separately from OneCall.
+	Health *health.Metrics
+	client *Client
+	config *config.OpenWeatherOneCall
+	log    *log.Logger
+	series *series.Tracker
+	// extraLabels are names of user-defined labels of all configured locations.
+	extraLabels   []string
+	currentGauges []oneCallCurrentGauge
+	hourlyGauges  []oneCallHourlyGauge
+	dailyGauges   []oneCallDailyGauge
+	alerts        *prometheus.GaugeVec
+}
+
+func NewOneCall(
+	client *Client,
+	config *config.OpenWeatherOneCall,
+	log *log.Logger,
+) *OneCall {
+	const namespace = "open_weather"
+	const subsystem = "one_call"
+	labels := []string{"location", "lat", "lon", "unit"}
+	extraLabels := extraLabelNames(config.Coords, labels, []string{"horizon"})
+	labels = append(labels, extraLabels...)
+	forecastLabels := append(labels[:len(labels):len(labels)], "horizon")
+
+	currentGauges := []oneCallCurrentGauge{
+		{name: "temp", value: func(c *OneCallCurrent) float64 { return c.Temp }},
+		{name: "feels_like", value: func(c *OneCallCurrent) float64 { return c.FeelsLike }},
+		{name: "pressure", value: func(c *OneCallCurrent) float64 { return c.Pressure }},
+		{name: "humidity", value: func(c *OneCallCurrent) float64 { return c.Humidity }},
+		{name: "dew_point", value: func(c *OneCallCurrent) float64 { return c.DewPoint }},
+		{name: "uvi", value: func(c *OneCallCurrent) float64 { return c.UVI }},
+		{name: "clouds", value: func(c *OneCallCurrent) float64 { return c.Clouds }},
+		{name: "visibility_meters", value: func(c *OneCallCurrent) float64 { return c.Visibility }},
+		{name: "wind_speed", value: func(c *OneCallCurrent) float64 { return c.WindSpeed }},
+		{name: "wind_deg", value: func(c *OneCallCurrent) float64 { return c.WindDeg }},
+		{name: "wind_gust", optional: func(c *OneCallCurrent) *float64 { return c.WindGust }},
+		{name: "rain_1h", optional: func(c *OneCallCurrent) *float64 { return c.Rain.OneHour }},
+		{name: "snow_1h", optional: func(c *OneCallCurrent) *float64 { return c.Snow.OneHour }},
+		{name: "sunrise_timestamp_seconds", value: func(c *OneCallCurrent) float64 { return c.Sunrise }},
+		{name: "sunset_timestamp_seconds", value: func(c *OneCallCurrent) float64 { return c.Sunset }},
+		{name: "measurement_timestamp_seconds", value: func(c *OneCallCurrent) float64 { return float64(c.Dt) }},
+	}
+	for i := range currentGauges {
+		g := &currentGauges[i]
+		g.collector = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "current_" + g.name,
+		}, labels)
+	}
+
+	hourlyGauges := []oneCallHourlyGauge{
+		{name: "temp", value: func(h *OneCallHourly) float64 { return h.Temp }},
+		{name: "feels_like", value: func(h *OneCallHourly) float64 { return h.FeelsLike }},
+		{name: "pressure", value: func(h *OneCallHourly) float64 { return h.Pressure }},
+		{name: "humidity", value: func(h *OneCallHourly) float64 { return h.Humidity }},
+		{name: "dew_point", value: func(h *OneCallHourly) float64 { return h.DewPoint }},
+		{name: "uvi", value: func(h *OneCallHourly) float64 { return h.UVI }},
+		{name: "clouds", value: func(h *OneCallHourly) float64 { return h.Clouds }},
+		{name: "visibility_meters", value: func(h *OneCallHourly) float64 { return h.Visibility }},
+		{name: "wind_speed", value: func(h *OneCallHourly) float64 { return h.WindSpeed }},
+		{name: "wind_deg", value: func(h *OneCallHourly) float64 { return h.WindDeg }},
+		{name: "wind_gust", optional: func(h *OneCallHourly) *float64 { return h.WindGust }},
+		{name: "pop", value: func(h *OneCallHourly) float64 { return h.Pop }},
+		{name: "rain_1h", optional: func(h *OneCallHourly) *float64 { return h.Rain.OneHour }},
+		{name: "snow_1h", optional: func(h *OneCallHourly) *float64 { return h.Snow.OneHour }},
+		{name: "forecast_timestamp_seconds", value: func(h *OneCallHourly) float64 { return float64(h.Dt) }},
+	}
+	for i := range hourlyGauges {
+		g := &hourlyGauges[i]
+		g.collector = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "hourly_" + g.name,
+		}, forecastLabels)
+	}
+
+	dailyGauges := []oneCallDailyGauge{
+		{name: "temp_min", value: func(d *OneCallDaily) float64 { return d.Temp.Min }},
+		{name: "temp_max", value: func(d *OneCallDaily) float64 { return d.Temp.Max }},
+		{name: "temp_day", value: func(d *OneCallDaily) float64 { return d.Temp.Day }},
+		{name: "temp_night", value: func(d *OneCallDaily) float64 { return d.Temp.Night }},
+		{name: "feels_like_day", value: func(d *OneCallDaily) float64 { return d.FeelsLike.Day }},
+		{name: "feels_like_night", value: func(d *OneCallDaily) float64 { return d.FeelsLike.Night }},
+		{name: "pressure", value: func(d *OneCallDaily) float64 { return d.Pressure }},
+		{name: "humidity", value: func(d *OneCallDaily) float64 { return d.Humidity }},
+		{name: "dew_point", value: func(d *OneCallDaily) float64 { return d.DewPoint }},
+		{name: "uvi", value: func(d *OneCallDaily) float64 { return d.UVI }},
+		{name: "clouds", value: func(d *OneCallDaily) float64 { return d.Clouds }},
+		{name: "wind_speed", value: func(d *OneCallDaily) float64 { return d.WindSpeed }},
+		{name: "wind_deg", value: func(d *OneCallDaily) float64 { return d.WindDeg }},
+		{name: "wind_gust", optional: func(d *OneCallDaily) *float64 { return d.WindGust }},
+		{name: "pop", value: func(d *OneCallDaily) float64 { return d.Pop }},
+		{name: "rain", optional: func(d *OneCallDaily) *float64 { return d.Rain }},
+		{name: "snow", optional: func(d *OneCallDaily) *float64 { return d.Snow }},
+		{name: "sunrise_timestamp_seconds", value: func(d *OneCallDaily) float64 { return d.Sunrise }},
+		{name: "sunset_timestamp_seconds", value: func(d *OneCallDaily) float64 { return d.Sunset }},
+		{name: "forecast_timestamp_seconds", value: func(d *OneCallDaily) float64 { return float64(d.Dt) }},
+	}
+	for i := range dailyGauges {
+		g := &dailyGauges[i]
+		g.collector = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "daily_" + g.name,
+		}, forecastLabels)
+	}
+
+	alerts := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "alerts",
+		Help:      "Number of active national weather alerts.",
+	}, labels)
+
+	return &OneCall{
+		Health:        health.NewMetrics(namespace, subsystem),
+		client:        client,
+		config:        config,
+		log:           log,
+		series:        series.NewTracker(),
+		extraLabels:   extraLabels,
+		currentGauges: currentGauges,
+		hourlyGauges:  hourlyGauges,
+		dailyGauges:   dailyGauges,
+		alerts:        alerts,
+	}
+}
+
+func (oc *OneCall) Describe(d chan<- *prometheus.Desc) {
+	for _, g := range oc.currentGauges {
+		g.collector.Describe(d)
+	}
+	for _, g := range oc.hourlyGauges {
+		g.collector.Describe(d)
+	}
+	for _, g := range oc.dailyGauges {
+		g.collector.Describe(d)
+	}
+	oc.alerts.Describe(d)
+}
+
+func (oc *OneCall) Collect(m chan<- prometheus.Metric) {
+	oc.series.Collect(m)
+}
+
+func (oc *OneCall) Run(ctx context.Context) {
+	oc.Update()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(oc.config.Interval)):
+			oc.Update()
+		}
+	}
+}
+
+func (oc *OneCall) Update() {
+	type result struct {
+		coords config.Coordinates
+		res    *OneCallResponse
+		err    error
+	}
+
+	results := make(chan result, len(oc.config.Coords))
+	start := time.Now()
+	var errs []error
+
+	for _, coords := range oc.config.Coords {
+		go func(coords config.Coordinates) {
+			res, err := oc.client.OneCall(location(&coords))
+			results <- result{coords, res, err}
+		}(coords)
+	}
+
+	for i := 0; i < len(oc.config.Coords); i++ {
+		result := <-results
+		if result.err != nil {
+			oc.log.Println("Error fetching One Call:", result.err)
+			errs = append(errs, result.err)
+			continue
+		}
+
+		labels := prometheus.Labels{
+			"location": result.coords.Name,
+			"lat":      strconv.FormatFloat(result.coords.Lat, 'f', -1, 64),
+			"lon":      strconv.FormatFloat(result.coords.Lon, 'f', -1, 64),
+			"unit":     unit(&result.coords),
+		}
+		for _, name := range oc.extraLabels {
+			labels[name] = result.coords.Labels[name]
+		}
+
+		oc.setCurrent(&result.res.Current, labels)
+		oc.setHourly(result.res.Hourly, labels)
+		oc.setDaily(result.res.Daily, labels)
+		oc.series.Set(oc.alerts, labels, float64(len(result.res.Alerts)))
+		// Grace period is for locations missing from responses,
+		// series of this one that were not set, like missing rain or shortened forecast, are deleted right away.
+		oc.series.DeleteStaleOf(labels, start)
+
+		oc.log.Printf("Processed One Call of %s", locationName(&result.coords))
+	}
+
+	oc.Health.Finish(health.Update{
+		Name:    "One Call",
+		Start:   start,
+		Targets: len(oc.config.Coords),
+		Errs:    errs,
+	}, oc.series, time.Duration(oc.config.StaleGracePeriod), oc.log)
+}
+
+func (oc *OneCall) setCurrent(current *OneCallCurrent, labels prometheus.Labels) {
+	var measuredAt time.Time
+	if oc.config.SampleTimestamps && current.Dt != 0 {
+		measuredAt = time.Unix(int64(current.Dt), 0)
+	}
+	for _, g := range oc.currentGauges {
+		if g.optional != nil {
+			if val := g.optional(current); val != nil {
+				oc.series.SetWithTimestamp(g.collector, labels, *val, measuredAt)
+			}
+			continue
+		}
+		oc.series.SetWithTimestamp(g.collector, labels, g.value(current), measuredAt)
+	}
+}
+
+// setHourly sets hourly forecast, first entry is the current hour which is covered by current weather and skipped.
+func (oc *OneCall) setHourly(hourly []OneCallHourly, labels prometheus.Labels) {
+	for i := 1; i < len(hourly) && (oc.config.Hours == 0 || i <= oc.config.Hours); i++ {
+		forecastLabels := withLabels(labels, prometheus.Labels{"horizon": fmt.Sprintf("+%dh", i)})
+		for _, g := range oc.hourlyGauges {
+			if g.optional != nil {
+				if val := g.optional(&hourly[i]); val != nil {
+					oc.series.Set(g.collector, forecastLabels, *val)
+				}
+				continue
+			}
+			oc.series.Set(g.collector, forecastLabels, g.value(&hourly[i]))
+		}
+	}
+}
+
+// setDaily sets daily forecast, first entry is today which is covered by current weather and skipped.
+func (oc *OneCall) setDaily(daily []OneCallDaily, labels prometheus.Labels) {
+	for i := 1; i < len(daily) && (oc.config.Days == 0 || i <= oc.config.Days); i++ {
+		forecastLabels := withLabels(labels, prometheus.Labels{"horizon": fmt.Sprintf("+%dd", i)})
+		for _, g := range oc.dailyGauges {
+			if g.optional != nil {
+				if val := g.optional(&daily[i]); val != nil {
+					oc.series.Set(g.collector, forecastLabels, *val)
+				}
+				continue
+			}
+			oc.series.Set(g.collector, forecastLabels, g.value(&daily[i]))
+		}
+	}
+}
+
+// locationName returns name of location for logs, falling back to coordinates when it is not named.
+func locationName(coords *config.Coordinates) string {
+	if coords.Name != "" {
+		return coords.Name
+	}
+	return fmt.Sprintf("%g,%g", coords.Lat, coords.Lon)
+}
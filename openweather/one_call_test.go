@@ -0,0 +1,355 @@
+package openweather_test
+
+import (
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+	"github.com/ulexxander/weather-prometheus-exporters/openweather"
+	"github.com/ulexxander/weather-prometheus-exporters/testutil"
+)
+
+func TestOneCall(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := openweather.NewClient("my-app-id")
+	client.OneCallURL = server.URL
+
+	config := &config.OpenWeatherOneCall{
+		Coords: []config.Coordinates{
+			{
+				Lat:   46.2389,
+				Lon:   14.3556,
+				Units: openweather.UnitsMetric,
+				Name:  "Office",
+			},
+		},
+		Hours: 1,
+	}
+	oneCall := openweather.NewOneCall(client, config, log.Default())
+
+	reg := prometheus.NewRegistry()
+	err := reg.Register(oneCall)
+	require.NoError(t, err)
+
+	updated := make(chan struct{})
+	go func() {
+		oneCall.Update()
+		updated <- struct{}{}
+	}()
+
+	var r *http.Request
+	select {
+	case r = <-handler.Requests:
+		handler.Responses <- []byte(oneCallResponse)
+	case <-time.After(time.Second):
+		require.Fail(t, "request did not arrived")
+	}
+
+	<-updated
+
+	require.Equal(t, "/onecall", r.URL.Path)
+	expectedQuery := url.Values{}
+	expectedQuery.Set("appid", "my-app-id")
+	expectedQuery.Set("lat", "46.2389")
+	expectedQuery.Set("lon", "14.3556")
+	expectedQuery.Set("exclude", "minutely")
+	expectedQuery.Set("units", "metric")
+	require.Equal(t, expectedQuery, r.URL.Query())
+
+	gatheredMetrics, err := reg.Gather()
+	require.NoError(t, err)
+
+	// Values by metric name and horizon label.
+	values := map[string]map[string]float64{}
+	for _, family := range gatheredMetrics {
+		values[family.GetName()] = map[string]float64{}
+		for _, metric := range family.Metric {
+			labels := map[string]string{}
+			for _, label := range metric.Label {
+				labels[label.GetName()] = label.GetValue()
+			}
+			require.Equal(t, "Office", labels["location"], family.GetName())
+			require.Equal(t, "46.2389", labels["lat"], family.GetName())
+			require.Equal(t, "14.3556", labels["lon"], family.GetName())
+			require.Equal(t, "metric", labels["unit"], family.GetName())
+			values[family.GetName()][labels["horizon"]] = metric.GetGauge().GetValue()
+		}
+	}
+
+	require.Equal(t, map[string]float64{"": 14.7}, values["open_weather_one_call_current_temp"])
+	require.Equal(t, map[string]float64{"": 8.1}, values["open_weather_one_call_current_dew_point"])
+	require.Equal(t, map[string]float64{"": 3.2}, values["open_weather_one_call_current_uvi"])
+	require.Equal(t, map[string]float64{"": 1651487420}, values["open_weather_one_call_current_measurement_timestamp_seconds"])
+	require.NotContains(t, values, "open_weather_one_call_current_wind_gust")
+	require.NotContains(t, values, "open_weather_one_call_current_rain_1h")
+
+	// Current hour is skipped and hourly forecast is limited to 1 hour.
+	require.Equal(t, map[string]float64{"+1h": 15.2}, values["open_weather_one_call_hourly_temp"])
+	require.Equal(t, map[string]float64{"+1h": 0.35}, values["open_weather_one_call_hourly_pop"])
+	require.Equal(t, map[string]float64{"+1h": 0.4}, values["open_weather_one_call_hourly_rain_1h"])
+	require.Equal(t, map[string]float64{"+1h": 1651492800}, values["open_weather_one_call_hourly_forecast_timestamp_seconds"])
+
+	// Today is skipped.
+	require.Equal(t, map[string]float64{"+1d": 7.3, "+2d": 9.1}, values["open_weather_one_call_daily_temp_min"])
+	require.Equal(t, map[string]float64{"+1d": 4.5, "+2d": 5.1}, values["open_weather_one_call_daily_uvi"])
+	require.Equal(t, map[string]float64{"+1d": 0.92, "+2d": 0.1}, values["open_weather_one_call_daily_pop"])
+	require.Equal(t, map[string]float64{"+1d": 12.5}, values["open_weather_one_call_daily_rain"])
+
+	require.Equal(t, map[string]float64{"": 1}, values["open_weather_one_call_alerts"])
+}
+
+func TestOneCall_MissingOptionalFields(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := openweather.NewClient("my-app-id")
+	client.OneCallURL = server.URL
+
+	config := &config.OpenWeatherOneCall{
+		Coords: []config.Coordinates{
+			{
+				Lat: 46.2389,
+				Lon: 14.3556,
+			},
+		},
+		Hours:            1,
+		StaleGracePeriod: config.Duration(10 * time.Minute),
+	}
+	oneCall := openweather.NewOneCall(client, config, log.Default())
+
+	reg := prometheus.NewRegistry()
+	err := reg.Register(oneCall)
+	require.NoError(t, err)
+
+	update := func(response interface{}) {
+		updated := make(chan struct{})
+		go func() {
+			oneCall.Update()
+			updated <- struct{}{}
+		}()
+
+		select {
+		case <-handler.Requests:
+			handler.Responses <- response
+		case <-time.After(time.Second):
+			require.Fail(t, "request did not arrived")
+		}
+
+		<-updated
+	}
+
+	update([]byte(oneCallResponse))
+	require.Equal(t, 1, promtestutil.CollectAndCount(oneCall, "open_weather_one_call_hourly_rain_1h"))
+
+	// Rain is no longer forecast for the next hour.
+	update([]byte(strings.Replace(oneCallResponse, `"pop": 0.35,
+      "rain": { "1h": 0.4 }`, `"pop": 0.35`, 1)))
+	require.Equal(t, 0, promtestutil.CollectAndCount(oneCall, "open_weather_one_call_hourly_rain_1h"))
+	require.Equal(t, 1, promtestutil.CollectAndCount(oneCall, "open_weather_one_call_hourly_temp"))
+}
+
+func TestOneCall_Error(t *testing.T) {
+	handler := testutil.NewHTTPHandler()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := openweather.NewClient("my-app-id")
+	client.OneCallURL = server.URL
+
+	config := &config.OpenWeatherOneCall{
+		Coords: []config.Coordinates{
+			{
+				Lat: 46.2389,
+				Lon: 14.3556,
+			},
+		},
+	}
+	oneCall := openweather.NewOneCall(client, config, log.Default())
+
+	reg := prometheus.NewRegistry()
+	err := reg.Register(oneCall.Health)
+	require.NoError(t, err)
+
+	updated := make(chan struct{})
+	go func() {
+		oneCall.Update()
+		updated <- struct{}{}
+	}()
+
+	select {
+	case <-handler.Requests:
+		handler.Responses <- openweather.ErrorResponse{
+			Cod:     401,
+			Message: "Please note that using One Call 3.0 requires a separate subscription.",
+		}
+	case <-time.After(time.Second):
+		require.Fail(t, "request did not arrived")
+	}
+
+	<-updated
+
+	gatheredMetrics, err := reg.Gather()
+	require.NoError(t, err)
+
+	var up, apiErrors float64
+	for _, family := range gatheredMetrics {
+		switch family.GetName() {
+		case "open_weather_one_call_up":
+			up = family.Metric[0].GetGauge().GetValue()
+		case "open_weather_one_call_update_errors_total":
+			for _, label := range family.Metric[0].Label {
+				if label.GetName() == "code" {
+					require.Equal(t, "401", label.GetValue())
+				}
+			}
+			apiErrors = family.Metric[0].GetCounter().GetValue()
+		}
+	}
+	require.Equal(t, 0.0, up)
+	require.Equal(t, 1.0, apiErrors)
+}
+
+const oneCallResponse = `{
+  "lat": 46.2389,
+  "lon": 14.3556,
+  "timezone": "Europe/Ljubljana",
+  "timezone_offset": 7200,
+  "current": {
+    "dt": 1651487420,
+    "sunrise": 1651463259,
+    "sunset": 1651515081,
+    "temp": 14.7,
+    "feels_like": 14.1,
+    "pressure": 1015,
+    "humidity": 72,
+    "dew_point": 8.1,
+    "uvi": 3.2,
+    "clouds": 75,
+    "visibility": 10000,
+    "wind_speed": 3.6,
+    "wind_deg": 290,
+    "weather": [
+      { "id": 803, "main": "Clouds", "description": "broken clouds", "icon": "04d" }
+    ]
+  },
+  "hourly": [
+    {
+      "dt": 1651485600,
+      "temp": 14.7,
+      "feels_like": 14.1,
+      "pressure": 1015,
+      "humidity": 72,
+      "dew_point": 8.1,
+      "uvi": 3.2,
+      "clouds": 75,
+      "visibility": 10000,
+      "wind_speed": 3.6,
+      "wind_deg": 290,
+      "wind_gust": 5.1,
+      "pop": 0.2
+    },
+    {
+      "dt": 1651492800,
+      "temp": 15.2,
+      "feels_like": 14.6,
+      "pressure": 1014,
+      "humidity": 70,
+      "dew_point": 8.3,
+      "uvi": 2.9,
+      "clouds": 90,
+      "visibility": 10000,
+      "wind_speed": 4.1,
+      "wind_deg": 280,
+      "wind_gust": 6.3,
+      "pop": 0.35,
+      "rain": { "1h": 0.4 }
+    },
+    {
+      "dt": 1651496400,
+      "temp": 14.9,
+      "feels_like": 14.3,
+      "pressure": 1014,
+      "humidity": 74,
+      "dew_point": 8.6,
+      "uvi": 2.1,
+      "clouds": 100,
+      "visibility": 9000,
+      "wind_speed": 3.8,
+      "wind_deg": 270,
+      "wind_gust": 5.9,
+      "pop": 0.6
+    }
+  ],
+  "daily": [
+    {
+      "dt": 1651482000,
+      "sunrise": 1651463259,
+      "sunset": 1651515081,
+      "temp": { "day": 15.1, "min": 6.2, "max": 17.4, "night": 9.8, "eve": 14.2, "morn": 6.9 },
+      "feels_like": { "day": 14.5, "night": 9.1, "eve": 13.6, "morn": 5.8 },
+      "pressure": 1015,
+      "humidity": 70,
+      "dew_point": 8.0,
+      "wind_speed": 4.2,
+      "wind_deg": 285,
+      "wind_gust": 8.4,
+      "clouds": 75,
+      "pop": 0.4,
+      "uvi": 5.3
+    },
+    {
+      "dt": 1651568400,
+      "sunrise": 1651549583,
+      "sunset": 1651601554,
+      "temp": { "day": 13.2, "min": 7.3, "max": 14.8, "night": 8.7, "eve": 12.1, "morn": 7.9 },
+      "feels_like": { "day": 12.6, "night": 8.1, "eve": 11.5, "morn": 6.8 },
+      "pressure": 1009,
+      "humidity": 88,
+      "dew_point": 11.2,
+      "wind_speed": 5.3,
+      "wind_deg": 240,
+      "wind_gust": 11.2,
+      "clouds": 100,
+      "pop": 0.92,
+      "rain": 12.5,
+      "uvi": 4.5
+    },
+    {
+      "dt": 1651654800,
+      "sunrise": 1651635908,
+      "sunset": 1651688027,
+      "temp": { "day": 16.4, "min": 9.1, "max": 18.9, "night": 11.3, "eve": 15.7, "morn": 9.6 },
+      "feels_like": { "day": 15.9, "night": 10.7, "eve": 15.1, "morn": 8.9 },
+      "pressure": 1013,
+      "humidity": 65,
+      "dew_point": 9.4,
+      "wind_speed": 2.7,
+      "wind_deg": 190,
+      "clouds": 40,
+      "pop": 0.1,
+      "uvi": 5.1
+    }
+  ],
+  "alerts": [
+    {
+      "sender_name": "ARSO",
+      "event": "Thunderstorms",
+      "start": 1651500000,
+      "end": 1651530000,
+      "description": "Isolated thunderstorms with hail are possible.",
+      "tags": ["Thunderstorm"]
+    }
+  ]
+}`
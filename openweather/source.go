@@ -0,0 +1,187 @@
+package openweather
+
+import (
+	"log"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+	"github.com/ulexxander/weather-prometheus-exporters/source"
+)
+
+func init() {
+	source.Register(&Source{})
+}
+
+// Source runs OpenWeather collectors enabled in config.
+type Source struct{}
+
+func (s *Source) Name() string {
+	return "OpenWeather"
+}
+
+func (s *Source) Enabled(cfg *config.Config) bool {
+	ow := &cfg.OpenWeather
+	return ow.CurrentWeatherData.Enabled || ow.OneCall.Enabled || ow.AirPollution.Enabled || ow.Forecast.Enabled
+}
+
+func (s *Source) Validate(cfg *config.Config) error {
+	var v config.Validator
+	ow := &cfg.OpenWeather
+	if ow.CurrentWeatherData.Enabled {
+		v.Interval("OpenWeather.CurrentWeatherData", ow.CurrentWeatherData.Interval)
+		v.Coords("OpenWeather.CurrentWeatherData", ow.CurrentWeatherData.Coords, builtinLocationLabels)
+	}
+	if ow.OneCall.Enabled {
+		v.Interval("OpenWeather.OneCall", ow.OneCall.Interval)
+		v.Coords("OpenWeather.OneCall", ow.OneCall.Coords, builtinLocationLabels)
+		v.LatLonOnly("OpenWeather.OneCall", ow.OneCall.Coords)
+		v.Limit("OpenWeather.OneCall.Hours", ow.OneCall.Hours, 47)
+		v.Limit("OpenWeather.OneCall.Days", ow.OneCall.Days, 7)
+	}
+	if ow.AirPollution.Enabled {
+		v.Interval("OpenWeather.AirPollution", ow.AirPollution.Interval)
+		validateFallbackCoords(&v, ow, "OpenWeather.AirPollution", ow.AirPollution.Coords)
+		validateFallbackLatLonOnly(&v, ow, "OpenWeather.AirPollution", ow.AirPollution.Coords)
+	}
+	if ow.Forecast.Enabled {
+		v.Interval("OpenWeather.Forecast", ow.Forecast.Interval)
+		validateFallbackCoords(&v, ow, "OpenWeather.Forecast", ow.Forecast.Coords)
+		v.Limit("OpenWeather.Forecast.Steps", ow.Forecast.Steps, 40)
+	}
+	return v.Err()
+}
+
+// builtinLocationLabels are labels that OpenWeather collectors export themselves.
+var builtinLocationLabels = map[string]bool{
+	"id": true, "name": true, "unit": true, "location": true,
+	"lat": true, "lon": true, "country": true, "timezone": true,
+	"condition_id": true, "main": true, "description": true, "icon": true,
+	"horizon": true, "offset": true,
+}
+
+// validateFallbackCoords validates Coords that fall back to Coords of Current Weather Data when they are not set.
+func validateFallbackCoords(v *config.Validator, ow *config.OpenWeather, path string, coords []config.Coordinates) {
+	if len(coords) > 0 {
+		v.Coords(path, coords, builtinLocationLabels)
+		return
+	}
+	if len(ow.CurrentWeatherData.Coords) == 0 {
+		v.Addf("%s.Coords must not be empty when OpenWeather.CurrentWeatherData.Coords are not set", path)
+		return
+	}
+	// Enabled Current Weather Data reports problems of its Coords itself.
+	if !ow.CurrentWeatherData.Enabled {
+		v.Coords("OpenWeather.CurrentWeatherData", ow.CurrentWeatherData.Coords, builtinLocationLabels)
+	}
+}
+
+// validateFallbackLatLonOnly reports locations not given by coordinates, including those of Current Weather Data that Coords fall back to.
+func validateFallbackLatLonOnly(v *config.Validator, ow *config.OpenWeather, path string, coords []config.Coordinates) {
+	if len(coords) > 0 {
+		v.LatLonOnly(path, coords)
+		return
+	}
+	for i := range ow.CurrentWeatherData.Coords {
+		if !ow.CurrentWeatherData.Coords[i].ByLatLon() {
+			v.Addf("%s.Coords must be set, OpenWeather.CurrentWeatherData.Coords[%d] they fall back to is not given by Lat and Lon", path, i)
+		}
+	}
+}
+
+func (s *Source) CheckCredentials(cfg *config.Config) error {
+	_, err := appID(&cfg.OpenWeather)
+	return err
+}
+
+func (s *Source) SecretFiles(cfg *config.Config) []string {
+	return source.AppendSecretFile(nil, cfg.OpenWeather.AppID, "OPEN_WEATHER_APP_ID")
+}
+
+func (s *Source) Jobs(cfg *config.Config, log *log.Logger) ([]*source.Job, error) {
+	config := &cfg.OpenWeather
+	if !config.CurrentWeatherData.Enabled {
+		log.Print("OpenWeather Current Weather Data is disabled")
+	}
+	if !config.OneCall.Enabled {
+		log.Print("OpenWeather One Call is disabled")
+	}
+	if !config.AirPollution.Enabled {
+		log.Print("OpenWeather Air Pollution is disabled")
+	}
+	if !config.Forecast.Enabled {
+		log.Print("OpenWeather Forecast is disabled")
+	}
+	if !s.Enabled(cfg) {
+		return nil, nil
+	}
+
+	appID, err := appID(config)
+	if err != nil {
+		return nil, err
+	}
+
+	client := NewClient(appID)
+	reg := prometheus.DefaultRegisterer
+	var jobs []*source.Job
+
+	if config.CurrentWeatherData.Enabled {
+		cwd := NewCurrentWeatherData(client, &config.CurrentWeatherData, log)
+		jobs = append(jobs, &source.Job{
+			Name:       "OpenWeather Current Weather Data",
+			Config:     []interface{}{appID, config.CurrentWeatherData},
+			Registerer: reg,
+			Collector:  cwd,
+			Health:     cwd.Health,
+		})
+	}
+
+	if config.OneCall.Enabled {
+		oneCall := NewOneCall(client, &config.OneCall, log)
+		jobs = append(jobs, &source.Job{
+			Name:       "OpenWeather One Call",
+			Config:     []interface{}{appID, config.OneCall},
+			Registerer: reg,
+			Collector:  oneCall,
+			Health:     oneCall.Health,
+		})
+	}
+
+	if config.AirPollution.Enabled {
+		// Fallback is set on a copy, so config that reload compares with stays as it was loaded.
+		apConfig := config.AirPollution
+		if len(apConfig.Coords) == 0 {
+			apConfig.Coords = config.CurrentWeatherData.Coords
+		}
+		airPollution := NewAirPollution(client, &apConfig, log)
+		jobs = append(jobs, &source.Job{
+			Name:       "OpenWeather Air Pollution",
+			Config:     []interface{}{appID, apConfig},
+			Registerer: reg,
+			Collector:  airPollution,
+			Health:     airPollution.Health,
+		})
+	}
+
+	if config.Forecast.Enabled {
+		forecastConfig := config.Forecast
+		if len(forecastConfig.Coords) == 0 {
+			forecastConfig.Coords = config.CurrentWeatherData.Coords
+		}
+		forecast := NewForecast(client, &forecastConfig, log)
+		jobs = append(jobs, &source.Job{
+			Name:       "OpenWeather Forecast",
+			Config:     []interface{}{appID, forecastConfig},
+			Registerer: reg,
+			Collector:  forecast,
+			Health:     forecast.Health,
+		})
+	}
+
+	return jobs, nil
+}
+
+func appID(config *config.OpenWeather) (string, error) {
+	var secrets source.Secrets
+	appID := secrets.Get("AppID", config.AppID, "OPEN_WEATHER_APP_ID")
+	return appID, secrets.Error()
+}
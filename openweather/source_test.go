@@ -0,0 +1,183 @@
+package openweather_test
+
+import (
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+	"github.com/ulexxander/weather-prometheus-exporters/openweather"
+)
+
+func TestSource_JobsFallbackCoords(t *testing.T) {
+	coords := []config.Coordinates{{Lat: 46.24, Lon: 14.36, Name: "kranj"}}
+	cfg := &config.Config{
+		OpenWeather: config.OpenWeather{
+			AppID: config.Secret{Value: "my-app-id"},
+			CurrentWeatherData: config.OpenWeatherCurrentWeatherData{
+				Coords: coords,
+			},
+			AirPollution: config.OpenWeatherAirPollution{Enabled: true, Interval: config.Duration(time.Minute)},
+			Forecast:     config.OpenWeatherForecast{Enabled: true, Interval: config.Duration(time.Minute)},
+		},
+	}
+
+	jobs, err := (&openweather.Source{}).Jobs(cfg, log.New(io.Discard, "", 0))
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+
+	// Jobs use fallback coords, but config is left as it was loaded.
+	require.Equal(t, coords, jobs[0].Config.([]interface{})[1].(config.OpenWeatherAirPollution).Coords)
+	require.Equal(t, coords, jobs[1].Config.([]interface{})[1].(config.OpenWeatherForecast).Coords)
+	require.Empty(t, cfg.OpenWeather.AirPollution.Coords)
+	require.Empty(t, cfg.OpenWeather.Forecast.Coords)
+}
+
+func TestSource_Validate(t *testing.T) {
+	cfg := &config.Config{
+		OpenWeather: config.OpenWeather{
+			CurrentWeatherData: config.OpenWeatherCurrentWeatherData{
+				Enabled:  true,
+				Interval: config.Duration(time.Minute),
+				Coords: []config.Coordinates{
+					{Lat: 46.24, Lon: 14.36},
+					{Lat: 95, Lon: 14.36},
+					{Lat: 46.24, Lon: 14.36},
+				},
+			},
+			OneCall: config.OpenWeatherOneCall{
+				Enabled:  true,
+				Interval: config.Duration(time.Minute),
+				Hours:    48,
+			},
+			// Falls back to Coords of Current Weather Data.
+			AirPollution: config.OpenWeatherAirPollution{
+				Enabled:  true,
+				Interval: config.Duration(time.Minute),
+			},
+		},
+	}
+
+	err := (&openweather.Source{}).Validate(cfg)
+	var validationErr *config.ValidationError
+	require.True(t, errors.As(err, &validationErr), err)
+	require.Equal(t, []string{
+		"OpenWeather.CurrentWeatherData.Coords[1].Lat must be between -90 and 90, got 95",
+		"OpenWeather.CurrentWeatherData.Coords[2] is a duplicate location",
+		"OpenWeather.OneCall.Coords must not be empty",
+		"OpenWeather.OneCall.Hours must be between 0 and 47, got 48",
+	}, validationErr.Problems)
+}
+
+func TestSource_ValidateDisabledCollectors(t *testing.T) {
+	cfg := &config.Config{
+		OpenWeather: config.OpenWeather{
+			CurrentWeatherData: config.OpenWeatherCurrentWeatherData{
+				Coords: []config.Coordinates{{Lat: 95}},
+			},
+		},
+	}
+	require.NoError(t, (&openweather.Source{}).Validate(cfg))
+}
+
+func TestSource_ValidateOneCallLatLonOnly(t *testing.T) {
+	cfg := &config.Config{
+		OpenWeather: config.OpenWeather{
+			OneCall: config.OpenWeatherOneCall{
+				Enabled:  true,
+				Interval: config.Duration(time.Minute),
+				Coords: []config.Coordinates{
+					{Lat: 46.24, Lon: 14.36},
+					{CityID: 3196359},
+					{Q: "Kranj,SI"},
+					{Zip: "4000,SI"},
+				},
+			},
+		},
+	}
+
+	err := (&openweather.Source{}).Validate(cfg)
+	var validationErr *config.ValidationError
+	require.True(t, errors.As(err, &validationErr), err)
+	require.Equal(t, []string{
+		"OpenWeather.OneCall.Coords[1] must be given by Lat and Lon, CityID, Q and Zip are not supported",
+		"OpenWeather.OneCall.Coords[2] must be given by Lat and Lon, CityID, Q and Zip are not supported",
+		"OpenWeather.OneCall.Coords[3] must be given by Lat and Lon, CityID, Q and Zip are not supported",
+	}, validationErr.Problems)
+}
+
+func TestSource_ValidateAirPollutionLatLonOnly(t *testing.T) {
+	tests := []struct {
+		name         string
+		cwdCoords    []config.Coordinates
+		coords       []config.Coordinates
+		wantProblems []string
+	}{
+		{
+			name:   "own coords",
+			coords: []config.Coordinates{{Lat: 46.24, Lon: 14.36}, {Q: "Kranj,SI"}},
+			wantProblems: []string{
+				"OpenWeather.AirPollution.Coords[1] must be given by Lat and Lon, CityID, Q and Zip are not supported",
+			},
+		},
+		{
+			name:      "fallback coords",
+			cwdCoords: []config.Coordinates{{Lat: 46.24, Lon: 14.36}, {CityID: 3196359}},
+			wantProblems: []string{
+				"OpenWeather.AirPollution.Coords must be set, OpenWeather.CurrentWeatherData.Coords[1] they fall back to is not given by Lat and Lon",
+			},
+		},
+		{
+			name:      "own coords with city based fallback",
+			cwdCoords: []config.Coordinates{{CityID: 3196359}},
+			coords:    []config.Coordinates{{Lat: 46.24, Lon: 14.36}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				OpenWeather: config.OpenWeather{
+					CurrentWeatherData: config.OpenWeatherCurrentWeatherData{
+						Coords: tt.cwdCoords,
+					},
+					AirPollution: config.OpenWeatherAirPollution{
+						Enabled:  true,
+						Interval: config.Duration(time.Minute),
+						Coords:   tt.coords,
+					},
+				},
+			}
+
+			err := (&openweather.Source{}).Validate(cfg)
+			if tt.wantProblems == nil {
+				require.NoError(t, err)
+				return
+			}
+			var validationErr *config.ValidationError
+			require.True(t, errors.As(err, &validationErr), err)
+			require.Equal(t, tt.wantProblems, validationErr.Problems)
+		})
+	}
+}
+
+func TestSource_ValidateBuiltinLabels(t *testing.T) {
+	cfg := &config.Config{
+		OpenWeather: config.OpenWeather{
+			CurrentWeatherData: config.OpenWeatherCurrentWeatherData{
+				Enabled:  true,
+				Interval: config.Duration(time.Minute),
+				Coords:   []config.Coordinates{{Lat: 46.24, Lon: 14.36, Labels: map[string]string{"timezone": "cet", "site": "hq"}}},
+			},
+		},
+	}
+
+	err := (&openweather.Source{}).Validate(cfg)
+	var validationErr *config.ValidationError
+	require.True(t, errors.As(err, &validationErr), err)
+	require.Equal(t, []string{
+		`OpenWeather.CurrentWeatherData.Coords[0].Labels name "timezone" clashes with built-in label`,
+	}, validationErr.Problems)
+}
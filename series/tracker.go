@@ -0,0 +1,170 @@
+package series
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Tracker sets gauges and remembers when each series was set last time,
+// so series that are no longer produced by updates can be deleted.
+type Tracker struct {
+	mu      sync.Mutex
+	entries map[key]*entry
+}
+
+type key struct {
+	vec    *prometheus.GaugeVec
+	labels string
+}
+
+type entry struct {
+	vec       *prometheus.GaugeVec
+	labels    prometheus.Labels
+	lastSet   time.Time
+	timestamp time.Time
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{
+		entries: map[key]*entry{},
+	}
+}
+
+// Set sets value of the series with given labels and marks it as fresh.
+func (t *Tracker) Set(vec *prometheus.GaugeVec, labels prometheus.Labels, value float64) {
+	t.SetWithTimestamp(vec, labels, value, time.Time{})
+}
+
+// SetWithTimestamp is like Set, but the series is collected with explicit sample timestamp.
+// Zero timestamp means the series is collected without it.
+func (t *Tracker) SetWithTimestamp(vec *prometheus.GaugeVec, labels prometheus.Labels, value float64, timestamp time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	k := key{vec, labelsKey(labels)}
+	e, ok := t.entries[k]
+	if !ok {
+		e = &entry{vec: vec, labels: labels}
+		t.entries[k] = e
+	}
+	e.lastSet = time.Now()
+	e.timestamp = timestamp
+	vec.With(labels).Set(value)
+}
+
+// SetInfo sets info series, which has labels and one more label name with value, to 1.
+// Series that differ from it only in value of that label are deleted,
+// so info metric has single series per object even right after the value changes.
+func (t *Tracker) SetInfo(vec *prometheus.GaugeVec, labels prometheus.Labels, name, value string, timestamp time.Time) {
+	infoLabels := make(prometheus.Labels, len(labels)+1)
+	for k, v := range labels {
+		infoLabels[k] = v
+	}
+	infoLabels[name] = value
+
+	t.mu.Lock()
+	for k, e := range t.entries {
+		if e.vec != vec || e.labels[name] == value || !sameLabelsExcept(e.labels, infoLabels, name) {
+			continue
+		}
+		vec.Delete(e.labels)
+		delete(t.entries, k)
+	}
+	t.mu.Unlock()
+
+	t.SetWithTimestamp(vec, infoLabels, 1, timestamp)
+}
+
+// Collect collects all tracked series, attaching sample timestamps to those that have it.
+func (t *Tracker) Collect(ch chan<- prometheus.Metric) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, e := range t.entries {
+		var m prometheus.Metric = e.vec.With(e.labels)
+		if !e.timestamp.IsZero() {
+			m = prometheus.NewMetricWithTimestamp(e.timestamp, m)
+		}
+		ch <- m
+	}
+}
+
+// DeleteStale deletes series that were not set since given time.
+// It returns how many series were deleted.
+func (t *Tracker) DeleteStale(since time.Time) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	deleted := 0
+	for k, e := range t.entries {
+		if !e.lastSet.Before(since) {
+			continue
+		}
+		e.vec.Delete(e.labels)
+		delete(t.entries, k)
+		deleted++
+	}
+	return deleted
+}
+
+// DeleteStaleOf is like DeleteStale, but deletes only series that have all given labels.
+// It is used for target that was updated successfully, so series that the update did not set,
+// like condition that has ended or field that is no longer in response, are deleted right away.
+func (t *Tracker) DeleteStaleOf(labels prometheus.Labels, since time.Time) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	deleted := 0
+	for k, e := range t.entries {
+		if !e.lastSet.Before(since) || !hasLabels(e.labels, labels) {
+			continue
+		}
+		e.vec.Delete(e.labels)
+		delete(t.entries, k)
+		deleted++
+	}
+	return deleted
+}
+
+func labelsKey(labels prometheus.Labels) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte(0xff)
+		b.WriteString(labels[name])
+		b.WriteByte(0xff)
+	}
+	return b.String()
+}
+
+func sameLabelsExcept(a, b prometheus.Labels, except string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if k != except && b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// hasLabels reports whether labels contain all of subset.
+func hasLabels(labels, subset prometheus.Labels) bool {
+	for k, v := range subset {
+		if val, ok := labels[k]; !ok || val != v {
+			return false
+		}
+	}
+	return true
+}
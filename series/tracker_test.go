@@ -0,0 +1,92 @@
+package series_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	"github.com/ulexxander/weather-prometheus-exporters/series"
+)
+
+func TestTracker(t *testing.T) {
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "temperature"}, []string{"id", "name"})
+	tracker := series.NewTracker()
+
+	tracker.Set(vec, prometheus.Labels{"id": "1", "name": "first"}, 10)
+	tracker.Set(vec, prometheus.Labels{"id": "2", "name": "second"}, 20)
+	require.Equal(t, 2, promtestutil.CollectAndCount(vec))
+
+	since := time.Now()
+	tracker.Set(vec, prometheus.Labels{"name": "first", "id": "1"}, 11)
+
+	deleted := tracker.DeleteStale(since)
+	require.Equal(t, 1, deleted)
+	require.Equal(t, 1, promtestutil.CollectAndCount(vec))
+	require.Equal(t, 11.0, promtestutil.ToFloat64(vec.WithLabelValues("1", "first")))
+
+	deleted = tracker.DeleteStale(since)
+	require.Equal(t, 0, deleted)
+}
+
+func TestTracker_DeleteStaleOf(t *testing.T) {
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "condition_info"}, []string{"id", "main"})
+	tracker := series.NewTracker()
+
+	tracker.Set(vec, prometheus.Labels{"id": "1", "main": "Rain"}, 1)
+	tracker.Set(vec, prometheus.Labels{"id": "2", "main": "Rain"}, 1)
+
+	since := time.Now()
+	tracker.Set(vec, prometheus.Labels{"id": "1", "main": "Clear"}, 1)
+
+	// Only the first target was updated, series of the second one are kept.
+	deleted := tracker.DeleteStaleOf(prometheus.Labels{"id": "1"}, since)
+	require.Equal(t, 1, deleted)
+	require.Equal(t, 2, promtestutil.CollectAndCount(vec))
+	require.Equal(t, 1.0, promtestutil.ToFloat64(vec.WithLabelValues("1", "Clear")))
+	require.Equal(t, 1.0, promtestutil.ToFloat64(vec.WithLabelValues("2", "Rain")))
+}
+
+func TestTracker_Collect(t *testing.T) {
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "temperature"}, []string{"id"})
+	tracker := series.NewTracker()
+
+	measuredAt := time.Unix(1651477543, 0)
+	tracker.SetWithTimestamp(vec, prometheus.Labels{"id": "1"}, 10, measuredAt)
+	tracker.Set(vec, prometheus.Labels{"id": "2"}, 20)
+
+	ch := make(chan prometheus.Metric, 2)
+	tracker.Collect(ch)
+	close(ch)
+
+	timestamps := map[string]int64{}
+	for m := range ch {
+		var metric dto.Metric
+		err := m.Write(&metric)
+		require.NoError(t, err)
+		timestamps[metric.Label[0].GetValue()] = metric.GetTimestampMs()
+	}
+	require.Equal(t, map[string]int64{"1": 1651477543000, "2": 0}, timestamps)
+}
+
+func TestTracker_SetInfo(t *testing.T) {
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "firmware_info"}, []string{"id", "firmware"})
+	tracker := series.NewTracker()
+
+	tracker.SetInfo(vec, prometheus.Labels{"id": "1"}, "firmware", "50", time.Time{})
+	tracker.SetInfo(vec, prometheus.Labels{"id": "2"}, "firmware", "50", time.Time{})
+	require.Equal(t, 2, promtestutil.CollectAndCount(vec))
+
+	// Upgrade of the first device replaces its series, the second one is kept.
+	tracker.SetInfo(vec, prometheus.Labels{"id": "1"}, "firmware", "51", time.Time{})
+	require.Equal(t, 2, promtestutil.CollectAndCount(vec))
+	require.Equal(t, 1.0, promtestutil.ToFloat64(vec.WithLabelValues("1", "51")))
+	require.Equal(t, 1.0, promtestutil.ToFloat64(vec.WithLabelValues("2", "50")))
+
+	ch := make(chan prometheus.Metric, 3)
+	tracker.Collect(ch)
+	close(ch)
+	require.Len(t, ch, 2)
+}
@@ -0,0 +1,7 @@
+// Package all registers all sources, it is imported for side effects only.
+package all
+
+import (
+	_ "github.com/ulexxander/weather-prometheus-exporters/netatmo"
+	_ "github.com/ulexxander/weather-prometheus-exporters/openweather"
+)
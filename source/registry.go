@@ -0,0 +1,41 @@
+package source
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+)
+
+var (
+	mu      sync.Mutex
+	sources []Source
+)
+
+// Register makes source available, it panics when source of the same name is already registered.
+// Section of SectionDecoder is registered in package config, so it is decoded with the rest of config.
+func Register(s Source) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, registered := range sources {
+		if registered.Name() == s.Name() {
+			panic(fmt.Sprintf("source %s is already registered", s.Name()))
+		}
+	}
+	if d, ok := s.(SectionDecoder); ok {
+		config.RegisterSection(s.Name(), d.NewSection)
+	}
+	sources = append(sources, s)
+}
+
+// All returns registered sources ordered by name.
+func All() []Source {
+	mu.Lock()
+	defer mu.Unlock()
+	all := append([]Source(nil), sources...)
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Name() < all[j].Name()
+	})
+	return all
+}
@@ -0,0 +1,21 @@
+package source_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ulexxander/weather-prometheus-exporters/source"
+	_ "github.com/ulexxander/weather-prometheus-exporters/source/all"
+)
+
+func TestAll(t *testing.T) {
+	var names []string
+	for _, s := range source.All() {
+		names = append(names, s.Name())
+	}
+	require.Equal(t, []string{"Netatmo", "OpenWeather"}, names)
+
+	require.Panics(t, func() {
+		source.Register(source.All()[0])
+	})
+}
@@ -0,0 +1,37 @@
+package source
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+)
+
+// Secrets resolves credentials, errors of all missing ones are reported together.
+type Secrets struct {
+	errs []string
+}
+
+// Get resolves secret, or env variable when secret is not configured.
+func (s *Secrets) Get(name string, secret config.Secret, defaultEnv string) string {
+	val, err := secret.WithDefaultEnv(defaultEnv).Resolve()
+	if err != nil {
+		s.errs = append(s.errs, fmt.Sprintf("%s: %s", name, err))
+	}
+	return val
+}
+
+func (s *Secrets) Error() error {
+	if len(s.errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("missing credentials: %s", strings.Join(s.errs, "; "))
+}
+
+// AppendSecretFile appends file that secret, or env variable when secret is not configured, is read from.
+func AppendSecretFile(files []string, secret config.Secret, defaultEnv string) []string {
+	if path := secret.WithDefaultEnv(defaultEnv).Path(); path != "" {
+		return append(files, path)
+	}
+	return files
+}
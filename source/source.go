@@ -0,0 +1,58 @@
+// Package source defines data sources, like OpenWeather or Netatmo, that are run by main without knowing about them.
+// Sources register themselves in init, importing package source/all makes all of them available.
+package source
+
+import (
+	"context"
+	"log"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ulexxander/weather-prometheus-exporters/config"
+	"github.com/ulexxander/weather-prometheus-exporters/health"
+)
+
+// Source constructs collectors of its enabled APIs.
+// Its section of config is a field of config.Config, or is decoded into config.Config.Sections when source implements SectionDecoder.
+// Only source itself validates it.
+type Source interface {
+	// Name is used in logs and errors, like "OpenWeather".
+	Name() string
+	// Validate reports all problems of enabled collectors as *config.ValidationError, disabled ones are not checked.
+	Validate(cfg *config.Config) error
+	// Enabled reports whether any collector of source is enabled.
+	Enabled(cfg *config.Config) bool
+	// CheckCredentials resolves credentials of enabled source, reporting all missing ones together.
+	CheckCredentials(cfg *config.Config) error
+	// SecretFiles returns files that credentials of enabled source are read from, config is reloaded when they change.
+	SecretFiles(cfg *config.Config) []string
+	// Jobs constructs enabled collectors, it is called with every loaded config, also when source is disabled.
+	Jobs(cfg *config.Config, log *log.Logger) ([]*Job, error)
+}
+
+// SectionDecoder is implemented by sources whose section is not a field of config.Config,
+// so adding such source does not require changes of package config.
+// Section is top-level key of the same name as source, it is decoded into value returned by NewSection
+// and is available in config.Config.Sections by that name.
+type SectionDecoder interface {
+	Source
+	// NewSection returns pointer to zero config of source, section of config file is decoded into it strictly.
+	NewSection() interface{}
+}
+
+// Collector updates its series periodically until context is done.
+type Collector interface {
+	prometheus.Collector
+	Run(ctx context.Context)
+}
+
+// Job is a collector with its health metrics, which is run in background.
+type Job struct {
+	// Name identifies job between reloads, like "OpenWeather One Call".
+	Name string
+	// Config is compared with config of running job of the same name on reload,
+	// job is restarted only when they differ.
+	Config     interface{}
+	Registerer prometheus.Registerer
+	Collector  Collector
+	Health     *health.Metrics
+}